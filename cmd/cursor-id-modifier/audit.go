@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/yuaotian/go-cursor-help/internal/audit"
+	"github.com/yuaotian/go-cursor-help/internal/config"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+)
+
+// runAudit implements the audit subcommand, which queries the audit log
+func runAudit(ctx context.Context, args []string) error {
+	fs := newFlagSet("audit")
+	since := fs.String("since", "", "only show entries at or after this date (YYYY-MM-DD or RFC 3339)")
+	until := fs.String("until", "", "only show entries before this date (YYYY-MM-DD or RFC 3339)")
+	field := fs.String("field", "", "only show entries that changed this field, e.g. telemetry.machineId")
+	asJSON := fs.Bool("json", jsonOutput(), "print entries as JSON Lines")
+	fs.Parse(args)
+
+	var filter audit.Filter
+	var err error
+	if filter.Since, err = parseAuditTime(*since); err != nil {
+		return err
+	}
+	if filter.Until, err = parseAuditTime(*until); err != nil {
+		return err
+	}
+	filter.Field = *field
+
+	auditLog, err := audit.NewLog(getCurrentUser(), false)
+	if err != nil {
+		return err
+	}
+	entries, err := auditLog.Query(filter)
+	if err != nil {
+		return err
+	}
+
+	for i := range entries {
+		redactAuditEntry(&entries[i])
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Println(lang.T("NoAuditEntries", lang.Args{"path": auditLog.Path()}))
+		return nil
+	}
+	for _, entry := range entries {
+		fmt.Println(lang.T("AuditEntry", lang.Args{
+			"time":    entry.Time.Local().Format("2006-01-02 15:04:05"),
+			"action":  fmt.Sprintf("%-7s", entry.Action),
+			"result":  fmt.Sprintf("%-7s", entry.Result),
+			"user":    entry.User,
+			"version": entry.Version,
+		}))
+		for _, c := range entry.Changes {
+			fmt.Printf("    %-24s %s -> %s\n", c.Field, orDash(c.Old), orDash(c.New))
+		}
+		if entry.Error != "" {
+			fmt.Println("    " + lang.T("AuditError", lang.Args{"error": entry.Error}))
+		}
+	}
+	return nil
+}
+
+// redactAuditEntry masks the identifiers in entry as diff and inspect do
+func redactAuditEntry(entry *audit.Entry) {
+	for i := range entry.Changes {
+		c := &entry.Changes[i]
+		redactor.Register(c.Old, c.New)
+		c.Old, c.New = redactor.Redact(c.Old), redactor.Redact(c.New)
+	}
+	entry.Error = redactor.Scrub(entry.Error)
+}
+
+// recordAudit appends the outcome of a configuration change to the audit log
+func recordAudit(username, action string, configManager *config.Manager, oldConfig, newConfig *config.StorageConfig, saveErr error) {
+	auditLog, err := audit.NewLog(username, *auditHash)
+	if err != nil {
+		log.Warn("Failed to open audit log:", err)
+		return
+	}
+
+	entry := audit.Entry{
+		User:       username,
+		Action:     action,
+		ConfigPath: configManager.ConfigPath(),
+		Version:    version,
+		Result:     audit.ResultSuccess,
+	}
+	if saveErr != nil {
+		entry.Result = audit.ResultFailure
+		entry.Error = saveErr.Error()
+	}
+
+	for _, key := range config.TelemetryKeys {
+		newValue, _ := newConfig.Get(key)
+		var oldValue string
+		if oldConfig != nil {
+			oldValue, _ = oldConfig.Get(key)
+		}
+		if oldValue != newValue {
+			entry.Changes = append(entry.Changes, audit.FieldChange{Field: key, Old: oldValue, New: newValue})
+		}
+	}
+
+	if err := auditLog.Append(entry); err != nil {
+		log.Warn("Failed to write audit log:", err)
+	}
+}
+
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD or RFC 3339", value)
+	}
+	return t, nil
+}
+
+func orDash(value string) string {
+	if strings.TrimSpace(value) == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+)
+
+// runBackup implements the backup command, which copies storage.json into
+// the backup directory or lists existing backups
+func runBackup(ctx context.Context, args []string) error {
+	fs := newFlagSet("backup")
+	list := fs.Bool("list", false, "list existing backups instead of creating one")
+	fs.Parse(args)
+
+	display := newDisplay()
+	configManager := initConfigManager(getCurrentUser())
+
+	if *list {
+		backups, err := configManager.ListBackups()
+		if err != nil {
+			return err
+		}
+		if len(backups) == 0 {
+			display.ShowInfo(lang.T("NoBackups", lang.Args{"dir": configManager.BackupDir()}))
+			return nil
+		}
+		for _, backup := range backups {
+			fmt.Println(backup)
+		}
+		return nil
+	}
+
+	backupPath, err := backupConfig(configManager)
+	if err != nil {
+		return err
+	}
+	if backupPath == "" {
+		display.ShowError(lang.T("NoConfigToBackUp"))
+		return errSilent
+	}
+	display.ShowSuccess(lang.T("BackupCreated", lang.Args{"path": backupPath}))
+	return nil
+}
+
+// runRestore implements the restore command, which replaces storage.json
+// with a backup after backing up the current file
+func runRestore(ctx context.Context, args []string) error {
+	fs := newFlagSet("restore")
+	file := fs.String("file", "", "backup to restore (default: the latest backup)")
+	readOnly := fs.Bool("r", false, "set storage.json to read-only mode")
+	fs.Parse(args)
+
+	username := getCurrentUser()
+	display := newDisplay()
+	configManager := initConfigManager(username)
+
+	backupPath := *file
+	if backupPath == "" {
+		latest, err := configManager.LatestBackup()
+		if err != nil {
+			return err
+		}
+		backupPath = latest
+	}
+
+	if newProcessManager().IsCursorRunning(ctx) {
+		display.ShowError(lang.T("CursorRunning"))
+		return errSilent
+	}
+
+	oldConfig, err := configManager.ReadConfig()
+	if err != nil {
+		log.Warn("Failed to read current config:", err)
+		oldConfig = nil
+	}
+
+	safetyBackup, err := backupConfig(configManager)
+	if err != nil {
+		return fmt.Errorf("failed to back up current configuration: %w", err)
+	}
+
+	err = configManager.RestoreBackup(ctx, backupPath, *readOnly)
+	newConfig, readErr := configManager.ReadConfig()
+	if err == nil && readErr != nil {
+		err = readErr
+	}
+	if newConfig != nil {
+		recordAudit(username, "restore", configManager, oldConfig, newConfig, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	display.ShowSuccess(lang.T("BackupRestoredName", lang.Args{"name": filepath.Base(backupPath)}))
+	if safetyBackup != "" {
+		display.ShowInfo(lang.T("PreviousConfigSaved", lang.Args{"path": safetyBackup}))
+	}
+	return nil
+}
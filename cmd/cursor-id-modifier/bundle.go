@@ -0,0 +1,238 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/yuaotian/go-cursor-help/internal/bundle"
+	"github.com/yuaotian/go-cursor-help/internal/config"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/internal/ui"
+	"github.com/yuaotian/go-cursor-help/pkg/idgen"
+)
+
+// passphraseEnvVar supplies the bundle passphrase without a prompt or file
+const passphraseEnvVar = "CURSOR_BUNDLE_PASSPHRASE"
+
+// runExport implements the export subcommand, which writes the current
+// identifiers into an identity bundle
+func runExport(ctx context.Context, args []string) error {
+	fs := newFlagSet("export")
+	output := fs.String("o", "", "path of the bundle file to write")
+	encrypt := fs.Bool("encrypt", false, "encrypt the bundle with a passphrase")
+	passphraseFile := fs.String("passphrase-file", "", "read the passphrase from this file (\"-\" for stdin)")
+	fs.Parse(args)
+
+	if *output == "" {
+		fs.Usage()
+		return fmt.Errorf("-o is required")
+	}
+
+	username := getCurrentUser()
+	configManager := initConfigManager(username)
+	current, err := configManager.ReadConfig()
+	if err != nil {
+		return err
+	}
+	if current == nil {
+		return fmt.Errorf("no configuration found at %s", configManager.ConfigPath())
+	}
+
+	b := &bundle.Bundle{
+		Metadata: bundle.Metadata{
+			CreatedAt:   time.Now().UTC(),
+			User:        username,
+			OS:          runtime.GOOS,
+			ToolVersion: version,
+			SourcePath:  configManager.ConfigPath(),
+		},
+		Fields: make(map[string]string),
+	}
+	b.Metadata.Hostname, _ = os.Hostname()
+	for _, key := range config.TelemetryKeys {
+		if value, _ := current.Get(key); value != "" {
+			b.Fields[key] = value
+		}
+	}
+
+	var passphrase []byte
+	if *encrypt || *passphraseFile != "" || os.Getenv(passphraseEnvVar) != "" {
+		if passphrase, err = readPassphrase(*passphraseFile, true); err != nil {
+			return err
+		}
+	}
+
+	if err := bundle.WriteFile(*output, b, passphrase); err != nil {
+		return err
+	}
+
+	display := newDisplay()
+	display.ShowSuccess(lang.T("BundleExported", lang.Args{"count": len(b.Fields), "path": *output}))
+	if passphrase == nil {
+		display.ShowInfo(lang.T("BundleNotEncrypted"))
+	}
+	return nil
+}
+
+// runImport implements the import subcommand, which applies an identity
+// bundle to the local storage.json after taking a backup
+func runImport(ctx context.Context, args []string) error {
+	fs := newFlagSet("import")
+	input := fs.String("i", "", "path of the bundle file to read")
+	passphraseFile := fs.String("passphrase-file", "", "read the passphrase from this file (\"-\" for stdin)")
+	readOnly := fs.Bool("r", false, "set storage.json to read-only mode")
+	fs.Parse(args)
+
+	if *input == "" {
+		fs.Usage()
+		return fmt.Errorf("-i is required")
+	}
+
+	b, err := bundle.ReadFile(*input, nil)
+	if err == bundle.ErrPassphraseRequired {
+		var passphrase []byte
+		if passphrase, err = readPassphrase(*passphraseFile, false); err != nil {
+			return err
+		}
+		b, err = bundle.ReadFile(*input, passphrase)
+	}
+	if err != nil {
+		return err
+	}
+
+	generator := idgen.NewGenerator()
+	for key, value := range b.Fields {
+		if err := validateField(generator, key, value); err != nil {
+			return fmt.Errorf("bundle rejected: %w", err)
+		}
+	}
+
+	username := getCurrentUser()
+	display := newDisplay()
+	configManager := initConfigManager(username)
+
+	if newProcessManager().IsCursorRunning(ctx) {
+		display.ShowError(lang.T("CursorRunning"))
+		return errSilent
+	}
+
+	oldConfig, err := configManager.ReadConfig()
+	if err != nil {
+		return err
+	}
+	newConfig := &config.StorageConfig{}
+	if oldConfig != nil {
+		*newConfig = *oldConfig
+	}
+	for key, value := range b.Fields {
+		newConfig.Set(key, value)
+		redactor.Register(value)
+	}
+
+	backupPath, err := backupConfig(configManager)
+	if err != nil {
+		return fmt.Errorf("failed to back up configuration: %w", err)
+	}
+	if backupPath != "" {
+		display.ShowInfo(lang.T("BackupSaved", lang.Args{"path": backupPath}))
+	}
+
+	err = configManager.SetFields(ctx, b.Fields, *readOnly)
+	recordAudit(username, "import", configManager, oldConfig, newConfig, err)
+	if err != nil {
+		return err
+	}
+
+	display.ShowSuccess(lang.T("BundleImported", lang.Args{
+		"path": *input,
+		"date": b.Metadata.CreatedAt.Local().Format("2006-01-02 15:04"),
+		"host": orDash(b.Metadata.Hostname),
+	}))
+	for _, key := range config.TelemetryKeys {
+		if value, ok := b.Fields[key]; ok {
+			display.ShowIdentifier(key, value)
+		}
+	}
+	return nil
+}
+
+// readPassphrase reads the bundle passphrase from the environment, a file,
+// or an interactive prompt, in that order. With confirm the prompt asks
+// twice, so that a typo cannot make a new bundle impossible to decrypt.
+func readPassphrase(path string, confirm bool) ([]byte, error) {
+	if value := os.Getenv(passphraseEnvVar); value != "" && path == "" {
+		return []byte(value), nil
+	}
+
+	var reader *bufio.Reader
+	switch path {
+	case "":
+		return promptPassphrase(confirm)
+	case "-":
+		reader = bufio.NewReader(os.Stdin)
+	default:
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open passphrase file: %w", err)
+		}
+		defer file.Close()
+		reader = bufio.NewReader(file)
+	}
+
+	passphrase, err := readPassphraseLine(reader)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(passphrase), nil
+}
+
+// promptPassphrase asks for the passphrase on stdin without echoing it
+func promptPassphrase(confirm bool) ([]byte, error) {
+	restore, err := ui.HideInput(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hide passphrase input: %w", err)
+	}
+	onInterrupt(restore)
+	defer restore()
+
+	reader := bufio.NewReader(os.Stdin)
+	fmt.Fprint(os.Stderr, lang.T("PassphrasePrompt"))
+	passphrase, err := readPassphraseLine(reader)
+	// The Enter that ended the input was not echoed either
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+
+	if confirm {
+		fmt.Fprint(os.Stderr, lang.T("PassphraseRepeatPrompt"))
+		again, err := readPassphraseLine(reader)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return nil, err
+		}
+		if again != passphrase {
+			return nil, errors.New(lang.T("PassphraseMismatch"))
+		}
+	}
+	return []byte(passphrase), nil
+}
+
+// readPassphraseLine reads one non-empty line from reader
+func readPassphraseLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	passphrase := strings.TrimRight(line, "\r\n")
+	if passphrase == "" {
+		if err != nil {
+			return "", fmt.Errorf("failed to read passphrase: %w", err)
+		}
+		return "", fmt.Errorf("passphrase must not be empty")
+	}
+	return passphrase, nil
+}
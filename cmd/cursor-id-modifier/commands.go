@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+)
+
+// command is a subcommand of the tool
+type command struct {
+	name    string
+	args    string // Argument synopsis shown in help, e.g. "[-json]"
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
+
+// defaultCommand runs when no subcommand is given, keeping the original
+// single-step behaviour of the tool
+const defaultCommand = "reset"
+
+// commands lists the subcommands in the order shown by help. It is filled
+// in init to avoid an initialization cycle with the command functions.
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"reset", "[-r] [-restart] [-wait [-timeout d] | -no-kill] [-json]", "close Cursor and replace all identifiers with new random ones", runReset},
+		{"inspect", "[-json]", "show the current identifiers and storage.json state", runInspect},
+		{"set", "-field KEY -value VALUE", "write a caller-supplied value into a single identifier", runSet},
+		{"unlock", "", "make storage.json writable again after reset -r", runUnlock},
+		{"backup", "[-list]", "back up storage.json, or list existing backups", runBackup},
+		{"restore", "[-file PATH]", "restore storage.json from a backup (default: the latest)", runRestore},
+		{"tui", "", "interactively review the state and regenerate selected identifiers", runTUI},
+		{"diff", "[-file PATH] [-json]", "compare storage.json with a backup (default: the latest)", runDiff},
+		{"export", "-o FILE [-encrypt]", "write the current identity to a bundle file", runExport},
+		{"import", "-i FILE", "apply an identity bundle after backing up storage.json", runImport},
+		{"audit", "[-since DATE] [-until DATE] [-field KEY]", "query the log of identifier changes", runAudit},
+		{"doctor", "[-json]", "diagnose problems with paths, permissions, identifiers and processes", runDoctor},
+		{"processes", "[-kill] [-json]", "list detected Cursor processes, the pattern that matched and near misses", runProcesses},
+		{"config", "show [-json]", "show the effective settings of the tool and where they came from", runConfig},
+		{"version", "", "show version information", runVersion},
+	}
+}
+
+// findCommand returns the subcommand with the given name, or nil
+func findCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// runCommand dispatches to the subcommand named by args and returns the
+// process exit code
+func runCommand(ctx context.Context, args []string) int {
+	name := defaultCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		if len(args) > 0 {
+			if cmd := findCommand(args[0]); cmd != nil {
+				// Flag sets exit after printing their usage for -h
+				cmd.run(ctx, []string{"-h"})
+				return 0
+			}
+		}
+		printUsage()
+		return 0
+	}
+
+	cmd := findCommand(name)
+	if cmd == nil {
+		fmt.Fprintln(os.Stderr, lang.T("UnknownCommand", lang.Args{"name": name})+"\n")
+		printUsage()
+		return 2
+	}
+
+	if err := cmd.run(ctx, args); err != nil {
+		if ctx.Err() != nil {
+			return exitInterrupted
+		}
+		if err != errSilent {
+			log.Error(err)
+		}
+		return 1
+	}
+	return 0
+}
+
+// errSilent signals failure after the command has already reported it
+var errSilent = errors.New("command failed")
+
+// newFlagSet creates the flag set of a subcommand with matching help text
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		out := fs.Output()
+		if cmd := findCommand(name); cmd != nil {
+			fmt.Fprintf(out, "Usage: %s %s %s\n\n%s\n", programName(), cmd.name, cmd.args, capitalize(cmd.summary))
+		}
+		hasFlags := false
+		fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+		if hasFlags {
+			fmt.Fprintln(out, "\nFlags:")
+			fs.PrintDefaults()
+		}
+	}
+	return fs
+}
+
+// printUsage prints the global help text
+func printUsage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage: %s [global flags] [command] [command flags]\n\n", programName())
+	fmt.Fprintf(out, "Without a command, %s runs %q.\n\nCommands:\n", programName(), defaultCommand)
+	for _, cmd := range commands {
+		fmt.Fprintf(out, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(out, "\nRun '%s help <command>' for the flags of a command.\n\nGlobal flags:\n", programName())
+	flag.PrintDefaults()
+}
+
+// printJSON writes v to stdout as indented JSON
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func programName() string {
+	return filepath.Base(os.Args[0])
+}
+
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:] + "."
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/internal/paths"
+	"github.com/yuaotian/go-cursor-help/internal/settings"
+)
+
+// runConfig implements the config command. Its only action, show, prints
+// the effective settings and the files they were loaded from.
+func runConfig(ctx context.Context, args []string) error {
+	fs := newFlagSet("config")
+	asJSON := fs.Bool("json", jsonOutput(), "print the result as JSON")
+	fs.Parse(args)
+
+	action := "show"
+	if fs.NArg() > 0 {
+		action = fs.Arg(0)
+	}
+	if action != "show" {
+		fs.Usage()
+		return errors.New(lang.T("UnknownConfigAction", lang.Args{"name": action}))
+	}
+
+	if *asJSON {
+		return printJSON(struct {
+			Files    []string           `json:"files"`
+			Settings *settings.Settings `json:"settings"`
+		}{append([]string{}, loadedFiles...), appSettings})
+	}
+
+	userDir, err := paths.ConfigDir(getCurrentUser())
+	if err != nil {
+		return err
+	}
+	fmt.Println("# " + lang.T("ConfigPrecedence", lang.Args{"prefix": settings.EnvPrefix}))
+	fmt.Println("# " + lang.T("ConfigUserFile", lang.Args{"path": filepath.Join(userDir, "config.{toml,yaml,yml,json}")}))
+	fmt.Println("# " + lang.T("ConfigSystemFile", lang.Args{"path": filepath.Join(paths.SystemConfigDir(), "config.{toml,yaml,yml,json}")}))
+	if len(loadedFiles) == 0 {
+		fmt.Println("# " + lang.T("ConfigLoadedNone"))
+	}
+	for _, file := range loadedFiles {
+		fmt.Println("# " + lang.T("ConfigLoaded", lang.Args{"path": file}))
+	}
+	if path, err := logFilePath(); err == nil && path != "" {
+		fmt.Println("# " + lang.T("ConfigLogFile", lang.Args{"path": path}))
+	}
+	return printJSON(appSettings)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/yuaotian/go-cursor-help/internal/config"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+)
+
+// Kinds of difference reported by diff
+const (
+	diffAdded   = "added"
+	diffRemoved = "removed"
+	diffChanged = "changed"
+)
+
+// keyDiff is a single top-level key that differs between two files
+type keyDiff struct {
+	Key  string `json:"key"`
+	Kind string `json:"kind"`
+	Old  string `json:"old,omitempty"`
+	New  string `json:"new,omitempty"`
+}
+
+// runDiff implements the diff command, which compares storage.json with a backup
+func runDiff(ctx context.Context, args []string) error {
+	fs := newFlagSet("diff")
+	file := fs.String("file", "", "backup to compare against (default: the latest backup)")
+	asJSON := fs.Bool("json", jsonOutput(), "print the differences as JSON")
+	fs.Parse(args)
+
+	configManager := initConfigManager(getCurrentUser())
+	backupPath := *file
+	if backupPath == "" {
+		latest, err := configManager.LatestBackup()
+		if err != nil {
+			return err
+		}
+		backupPath = latest
+	}
+
+	diffs, err := diffConfigFiles(backupPath, configManager.ConfigPath())
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(diffs)
+	}
+
+	display := newDisplay()
+	display.ShowInfo(fmt.Sprintf("--- %s\n+++ %s", backupPath, configManager.ConfigPath()))
+	if len(diffs) == 0 {
+		fmt.Println(lang.T("NoDifferences"))
+		return nil
+	}
+	for _, d := range diffs {
+		switch d.Kind {
+		case diffAdded:
+			fmt.Printf("+ %-24s %s\n", d.Key, d.New)
+		case diffRemoved:
+			fmt.Printf("- %-24s %s\n", d.Key, d.Old)
+		default:
+			fmt.Printf("~ %-24s %s -> %s\n", d.Key, d.Old, d.New)
+		}
+	}
+	return nil
+}
+
+// diffConfigFiles compares the top-level keys of two storage.json files.
+// Identifier values are redacted; other values are shown as compact JSON.
+func diffConfigFiles(oldPath, newPath string) ([]keyDiff, error) {
+	oldValues, err := config.ReadValues(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	newValues, err := config.ReadValues(newPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]struct{})
+	for k := range oldValues {
+		keys[k] = struct{}{}
+	}
+	for k := range newValues {
+		keys[k] = struct{}{}
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var diffs []keyDiff
+	for _, key := range sorted {
+		oldValue, inOld := oldValues[key]
+		newValue, inNew := newValues[key]
+		oldText, newText := diffValue(key, oldValue), diffValue(key, newValue)
+
+		switch {
+		case !inOld:
+			diffs = append(diffs, keyDiff{Key: key, Kind: diffAdded, New: newText})
+		case !inNew:
+			diffs = append(diffs, keyDiff{Key: key, Kind: diffRemoved, Old: oldText})
+		case compactJSON(oldValue) != compactJSON(newValue):
+			diffs = append(diffs, keyDiff{Key: key, Kind: diffChanged, Old: oldText, New: newText})
+		}
+	}
+	return diffs, nil
+}
+
+func diffValue(key string, value interface{}) string {
+	if _, ok := fieldIDTypes[key]; ok {
+		if s, ok := value.(string); ok {
+			redactor.Register(s)
+			return redactor.Redact(s)
+		}
+	}
+	const maxLen = 60
+	text := compactJSON(value)
+	if len(text) > maxLen {
+		text = text[:maxLen] + "…"
+	}
+	return text
+}
+
+func compactJSON(value interface{}) string {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(data)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/yuaotian/go-cursor-help/internal/doctor"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/internal/ui"
+	"github.com/yuaotian/go-cursor-help/pkg/idgen"
+)
+
+// runDoctor implements the doctor command, which checks the environment
+// for common problems and suggests fixes
+func runDoctor(ctx context.Context, args []string) error {
+	fs := newFlagSet("doctor")
+	asJSON := fs.Bool("json", jsonOutput(), "print the results as JSON")
+	fs.Parse(args)
+
+	username := getCurrentUser()
+	checker := &doctor.Checker{
+		Username:       username,
+		ConfigManager:  initConfigManager(username),
+		ProcessManager: newProcessManager(),
+		Generator:      idgen.NewGenerator(),
+		IsAdmin:        checkAdminPrivileges,
+		FieldIDTypes:   fieldIDTypes,
+		ProgramName:    programName(),
+	}
+	results := checker.Run(ctx)
+
+	if *asJSON {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else {
+		showDoctorResults(newDisplay(), results)
+	}
+
+	if doctor.Failed(results) {
+		return errSilent
+	}
+	return nil
+}
+
+func showDoctorResults(display *ui.Display, results []doctor.Result) {
+	failed, warned := 0, 0
+	for _, r := range results {
+		line := fmt.Sprintf("[%s] %s: %s", r.Status, r.Name, r.Message)
+		switch r.Status {
+		case doctor.Pass:
+			display.ShowSuccess(line)
+		case doctor.Warn:
+			warned++
+			display.ShowWarning(line)
+		default:
+			failed++
+			display.ShowError(line)
+		}
+		if r.Fix != "" {
+			fmt.Println("       " + lang.T("DoctorFix", lang.Args{"fix": r.Fix}))
+		}
+	}
+
+	fmt.Println()
+	summary := lang.T("DoctorSummary", lang.Args{lang.CountArg: len(results), "warnings": warned, "failures": failed})
+	if failed > 0 {
+		display.ShowError(summary)
+	} else {
+		display.ShowInfo(summary)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/yuaotian/go-cursor-help/internal/config"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/pkg/idgen"
+)
+
+// identifierInfo describes one identifier for inspect output
+type identifierInfo struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+	Valid bool   `json:"valid"`
+}
+
+// inspectResult is the state reported by the inspect command
+type inspectResult struct {
+	ConfigPath   string             `json:"configPath"`
+	Exists       bool               `json:"exists"`
+	Mode         string             `json:"mode,omitempty"`
+	ReadOnly     bool               `json:"readOnly"`
+	Lock         *config.LockRecord `json:"lock,omitempty"`
+	LastModified string             `json:"lastModified,omitempty"`
+	Identifiers  []identifierInfo   `json:"identifiers"`
+	Backups      int                `json:"backups"`
+}
+
+// runInspect implements the inspect command, which reports the current
+// identifiers without changing anything
+func runInspect(ctx context.Context, args []string) error {
+	fs := newFlagSet("inspect")
+	asJSON := fs.Bool("json", jsonOutput(), "print the result as JSON")
+	fs.Parse(args)
+
+	result, err := inspectConfig(initConfigManager(getCurrentUser()), idgen.NewGenerator())
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		return printJSON(result)
+	}
+
+	display := newDisplay()
+	display.ShowInfo(fmt.Sprintf("storage.json: %s", result.ConfigPath))
+	if !result.Exists {
+		display.ShowError(lang.T("ConfigMissing"))
+		return nil
+	}
+
+	fmt.Printf("  %-24s %s", lang.T("InspectMode")+":", result.Mode)
+	if result.ReadOnly {
+		fmt.Print(" " + lang.T("InspectReadOnly"))
+	}
+	fmt.Println()
+	if result.Lock != nil {
+		fmt.Printf("  %-24s %s\n", lang.T("InspectLocked")+":", lang.T("InspectLockDetail", lang.Args{
+			"time": result.Lock.LockedAt.Local().Format("2006-01-02 15:04:05"),
+			"mode": result.Lock.PreviousMode,
+		}))
+	}
+	if result.ReadOnly {
+		display.ShowWarning("  " + lang.T("ReadOnlyHint", lang.Args{"program": programName()}))
+	}
+	fmt.Printf("  %-24s %s\n", lang.T("InspectLastModified")+":", orDash(result.LastModified))
+	fmt.Printf("  %-24s %d\n", lang.T("InspectBackups")+":", result.Backups)
+	fmt.Println()
+
+	// Values are already redacted, so print them directly
+	for _, id := range result.Identifiers {
+		status := ""
+		if id.Value != "" && !id.Valid {
+			status = "  " + lang.T("InspectInvalid")
+		}
+		fmt.Printf("  %-24s %s%s\n", id.Field+":", orDash(id.Value), status)
+	}
+	return nil
+}
+
+// inspectConfig gathers the state of storage.json, with identifiers redacted
+func inspectConfig(configManager *config.Manager, generator *idgen.Generator) (*inspectResult, error) {
+	result := &inspectResult{ConfigPath: configManager.ConfigPath()}
+
+	info, err := os.Stat(configManager.ConfigPath())
+	if os.IsNotExist(err) {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+	result.Exists = true
+	result.Mode = info.Mode().Perm().String()
+	result.ReadOnly = info.Mode().Perm()&0222 == 0
+	if result.Lock, err = configManager.LockRecord(); err != nil {
+		log.Warn("Failed to read lock state:", err)
+	}
+
+	current, err := configManager.ReadConfig()
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		result.Exists = false
+		return result, nil
+	}
+	result.LastModified = current.LastModified
+
+	for _, key := range config.TelemetryKeys {
+		value, _ := current.Get(key)
+		redactor.Register(value)
+		result.Identifiers = append(result.Identifiers, identifierInfo{
+			Field: key,
+			Value: redactor.Redact(value),
+			Valid: generator.ValidateID(value, fieldIDTypes[key]),
+		})
+	}
+
+	if backups, err := configManager.ListBackups(); err == nil {
+		result.Backups = len(backups)
+	}
+	return result, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/yuaotian/go-cursor-help/internal/config"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/internal/ui"
+)
+
+// exitInterrupted is the exit code after Ctrl-C or SIGTERM, following the
+// shell convention of 128 + SIGINT
+const exitInterrupted = 130
+
+var (
+	interruptMu       sync.Mutex
+	interruptHandlers []func()
+	interruptDone     = make(chan struct{})
+)
+
+// onInterrupt registers fn to run when the tool is interrupted
+func onInterrupt(fn func()) {
+	interruptMu.Lock()
+	defer interruptMu.Unlock()
+	interruptHandlers = append(interruptHandlers, fn)
+}
+
+// handleSignals returns a context that is cancelled on Ctrl-C or SIGTERM.
+// Cancelling stops process and config operations; the registered handlers
+// then clean up and the tool exits with exitInterrupted, even when the
+// command is blocked, e.g. waiting for Enter.
+func handleSignals() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	go func() {
+		sig := <-signals
+		log.Debugf("Received %s, cleaning up", sig)
+		cancel()
+
+		interruptMu.Lock()
+		for _, fn := range interruptHandlers {
+			fn()
+		}
+		interruptMu.Unlock()
+
+		fmt.Fprintln(os.Stderr, lang.T("Interrupted"))
+		close(interruptDone)
+		os.Exit(exitInterrupted)
+	}()
+	return ctx
+}
+
+// waitInterrupted blocks until the interrupt handlers have finished when ctx
+// was cancelled by a signal
+func waitInterrupted(ctx context.Context) {
+	if ctx.Err() != nil {
+		<-interruptDone
+	}
+}
+
+// newDisplay creates a display on stdout whose spinner is stopped on
+// interrupt
+func newDisplay() *ui.Display {
+	return newDisplayTo(nil)
+}
+
+// newDisplayTo creates a display writing to out, or stdout if nil, whose
+// spinner is stopped on interrupt
+func newDisplayTo(out io.Writer) *ui.Display {
+	display := ui.NewDisplay(nil, out)
+	display.SetRedactor(redactor)
+	onInterrupt(display.StopProgress)
+	return display
+}
+
+// removeTempOnInterrupt removes a half-written storage.json.tmp on interrupt
+func removeTempOnInterrupt(configManager *config.Manager) {
+	onInterrupt(func() {
+		if err := configManager.RemoveTemp(); err != nil {
+			log.Warn(err)
+		}
+	})
+}
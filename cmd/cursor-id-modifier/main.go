@@ -4,9 +4,11 @@ import (
 	"bufio"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/user"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
 	"strings"
@@ -15,265 +17,258 @@ import (
 
 	"github.com/yuaotian/go-cursor-help/internal/config"
 	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/internal/logfile"
+	"github.com/yuaotian/go-cursor-help/internal/paths"
 	"github.com/yuaotian/go-cursor-help/internal/process"
+	"github.com/yuaotian/go-cursor-help/internal/redact"
+	"github.com/yuaotian/go-cursor-help/internal/settings"
 	"github.com/yuaotian/go-cursor-help/internal/ui"
-	"github.com/yuaotian/go-cursor-help/pkg/idgen"
 )
 
 // Global variables
 var (
 	version     = "dev"
-	setReadOnly = flag.Bool("r", false, "set storage.json to read-only mode")
+	setReadOnly = flag.Bool("r", false, "set storage.json to read-only mode (same as reset -r)")
 	showVersion = flag.Bool("v", false, "show version information")
+	auditHash   = flag.Bool("audit-hash", false, "record SHA-256 hashes instead of identifier values in the audit log")
+	redactMode  = flag.String("redact", "", "identifier display mode: none, partial or hash (default partial when output is not a terminal)")
+	outputMode  = flag.String("output", "", "output format for commands that support it: text or json")
+	configFile  = flag.String("config", "", "use this config file instead of the per-user one")
+	noColor     = flag.Bool("no-color", false, "disable coloured output (also disabled by the NO_COLOR environment variable)")
+	langFlag    = flag.String("lang", "", "user interface language as a BCP 47 tag, e.g. en, zh-CN, zh-TW, ja, ru, de or es (default from the locale)")
+	verbose     = flag.Bool("verbose", false, "show debug messages (same as -log-level debug)")
+	logLevel    = flag.String("log-level", "", "log level on stderr: trace, debug, info, warn or error (default info)")
+	logFile     = flag.String("log-file", "", "write the log to this file instead of the one in the per-user log directory, or \"off\"")
+	logFormat   = flag.String("log-format", "", "log format on stderr and in the log file: text or json (default text)")
 	log         = logrus.New()
+	redactor    *redact.Redactor
+	appSettings = settings.Default()
+	loadedFiles []string
+
+	// pauseOnExit makes waitExit wait for Enter, so that a console window
+	// opened by double-clicking stays open
+	pauseOnExit = true
 )
 
 func main() {
 	// Place defer at the beginning of main to ensure it can catch panics from all subsequent function calls
 	defer func() {
 		if r := recover(); r != nil {
-			log.Errorf("Panic recovered: %v\n", r)
+			log.Error(lang.T("ErrorPrefix", lang.Args{"error": r}))
 			debug.PrintStack()
 			waitExit()
 		}
 	}()
 
-	handleFlags()
+	args := handleFlags()
+	loadSettings()
 	setupLogger()
 
-	username := getCurrentUser()
-	log.Debug("Running as user:", username)
-
-	// Initialize components
-	display := ui.NewDisplay(nil)
-	configManager := initConfigManager(username)
-	generator := idgen.NewGenerator()
-	processManager := process.NewManager(nil, log)
+	ctx := handleSignals()
+	code := runCommand(ctx, args)
+	waitInterrupted(ctx)
+	os.Exit(code)
+}
 
-	// Check and handle privileges
-	if err := handlePrivileges(display); err != nil {
-		return
+// handleFlags parses the global flags and returns the remaining arguments
+func handleFlags() []string {
+	flag.Usage = printUsage
+	flag.Parse()
+	if *showVersion {
+		printVersion()
+		os.Exit(0)
 	}
+	return flag.Args()
+}
 
-	// Setup display
-	setupDisplay(display)
-
-	text := lang.GetText()
-
-	// Handle Cursor processes
-	if err := handleCursorProcesses(display, processManager); err != nil {
-		return
+// loadSettings applies config files and environment, then flags, on top
+// of the defaults
+func loadSettings() {
+	loaded, err := settings.Load(getCurrentUser(), *configFile, settings.Flags{
+		Language:  *langFlag,
+		Output:    *outputMode,
+		Redact:    *redactMode,
+		Verbose:   *verbose,
+		LogLevel:  *logLevel,
+		LogFile:   *logFile,
+		LogFormat: *logFormat,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, lang.T("InvalidConfiguration", lang.Args{"error": err}))
+		os.Exit(2)
 	}
+	appSettings, loadedFiles = loaded.Settings, loaded.Files
 
-	// Handle configuration
-	oldConfig := readExistingConfig(display, configManager, text)
-	newConfig := generateNewConfig(display, generator, oldConfig, text)
-
-	if err := saveConfiguration(display, configManager, newConfig); err != nil {
-		return
+	if appSettings.LocaleDir != "" {
+		lang.SetCatalogDir(appSettings.LocaleDir)
 	}
-
-	// Show completion messages
-	showCompletionMessages(display)
-
-	if os.Getenv("AUTOMATED_MODE") != "1" {
-		waitExit()
+	if appSettings.Language != "" {
+		language, ok := lang.Match(appSettings.Language)
+		if !ok {
+			err := lang.T("UnsupportedLanguage", lang.Args{"language": appSettings.Language, "supported": lang.Supported()})
+			fmt.Fprintln(os.Stderr, lang.T("InvalidConfiguration", lang.Args{"error": err}))
+			os.Exit(2)
+		}
+		lang.SetLanguage(language)
 	}
 }
 
-func handleFlags() {
-	flag.Parse()
-	if *showVersion {
-		fmt.Printf("Cursor ID Modifier v%s\n", version)
-		os.Exit(0)
-	}
+// jsonOutput reports whether commands should default to JSON output
+func jsonOutput() bool {
+	return appSettings.Output == settings.OutputJSON
 }
 
-func setupLogger() {
-	log.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp:          true,
-		DisableLevelTruncation: true,
-		PadLevelText:           true,
-	})
-	log.SetLevel(logrus.InfoLevel)
+// newProcessManager creates a process manager using the configured patterns and retries
+func newProcessManager() *process.Manager {
+	return process.NewManager(appSettings.ProcessConfig(), log)
 }
 
-func getCurrentUser() string {
-	if username := os.Getenv("SUDO_USER"); username != "" {
-		return username
+// backupConfig backs up storage.json and prunes backups beyond the configured retention
+func backupConfig(configManager *config.Manager) (string, error) {
+	backupPath, err := configManager.Backup()
+	if err != nil || backupPath == "" {
+		return backupPath, err
 	}
-
-	user, err := user.Current()
-	if err != nil {
-		log.Fatal(err)
+	if removed, err := configManager.PruneBackups(appSettings.Backup.Retention); err != nil {
+		log.Warn("Failed to prune old backups:", err)
+	} else if len(removed) > 0 {
+		log.Debugf("Pruned %d old backups", len(removed))
 	}
-	return user.Username
+	return backupPath, nil
 }
 
-func initConfigManager(username string) *config.Manager {
-	configManager, err := config.NewManager(username)
-	if err != nil {
-		log.Fatal(err)
+// setupLogger logs at the configured level to stderr and at least at debug
+// level to the log file, so that the file can be attached to bug reports
+func setupLogger() {
+	setupRedactor()
+	if *noColor {
+		ui.DisableColor()
 	}
-	return configManager
-}
 
-func handlePrivileges(display *ui.Display) error {
-	isAdmin, err := checkAdminPrivileges()
-	if err != nil {
-		log.Error(err)
-		waitExit()
-		return err
-	}
+	level, _ := logrus.ParseLevel(appSettings.Log.Level) // Checked by Validate
+	log.SetOutput(io.Discard)
+	log.SetLevel(level)
+	log.AddHook(&logfile.Hook{Writer: os.Stderr, Level: level, Formatter: logFormatter(redactor, ui.ColorEnabled(os.Stderr))})
 
-	if !isAdmin {
-		if runtime.GOOS == "windows" {
-			return handleWindowsPrivileges(display)
-		}
-		display.ShowPrivilegeError(
-			lang.GetText().PrivilegeError,
-			lang.GetText().RunWithSudo,
-			lang.GetText().SudoExample,
-		)
-		waitExit()
-		return fmt.Errorf("insufficient privileges")
+	file := openLogFile()
+	if file == nil {
+		return
+	}
+	// Identifiers shown in full on a terminal are still masked in the file
+	fileRedactor := redactor
+	if appSettings.Redact == "" && redactor.Mode() == redact.None {
+		fileRedactor = redactor.WithMode(redact.Partial)
 	}
-	return nil
+	if level < logrus.DebugLevel {
+		level = logrus.DebugLevel
+	}
+	log.SetLevel(level)
+	log.AddHook(&logfile.Hook{Writer: file, Level: level, Formatter: logFormatter(fileRedactor, false)})
+	// Flag values and command arguments may hold identifiers, so only names are logged
+	var flagNames []string
+	flag.Visit(func(f *flag.Flag) { flagNames = append(flagNames, f.Name) })
+	log.WithFields(logrus.Fields{"version": version, "os": runtime.GOOS, "arch": runtime.GOARCH, "command": flag.Arg(0), "flags": flagNames}).Debug("Starting")
 }
 
-func handleWindowsPrivileges(display *ui.Display) error {
-	message := "\nRequesting administrator privileges..."
-	if lang.GetCurrentLanguage() == lang.CN {
-		message = "\n请求管理员权限..."
+// logFormatter formats log entries in the configured format and masks
+// identifiers with r
+func logFormatter(r *redact.Redactor, colors bool) logrus.Formatter {
+	var formatter logrus.Formatter = &logrus.TextFormatter{
+		FullTimestamp:          true,
+		DisableLevelTruncation: true,
+		PadLevelText:           true,
+		ForceColors:            colors,
+		DisableColors:          !colors,
 	}
-	fmt.Println(message)
-
-	if err := selfElevate(); err != nil {
-		log.Error(err)
-		display.ShowPrivilegeError(
-			lang.GetText().PrivilegeError,
-			lang.GetText().RunAsAdmin,
-			lang.GetText().RunWithSudo,
-			lang.GetText().SudoExample,
-		)
-		waitExit()
-		return err
+	if appSettings.Log.Format == settings.OutputJSON {
+		formatter = &logrus.JSONFormatter{}
 	}
-	return nil
+	return &redact.Formatter{Redactor: r, Formatter: formatter}
 }
 
-func setupDisplay(display *ui.Display) {
-	if err := display.ClearScreen(); err != nil {
-		log.Warn("Failed to clear screen:", err)
+// logFilePath returns the configured log file, or an empty path if the log
+// file is turned off
+func logFilePath() (string, error) {
+	switch appSettings.Log.File {
+	case settings.LogFileOff:
+		return "", nil
+	case "":
+		dir, err := paths.LogDir(getCurrentUser())
+		if err != nil {
+			return "", fmt.Errorf("failed to get log directory: %w", err)
+		}
+		return filepath.Join(dir, logfile.FileName), nil
 	}
-	display.ShowLogo()
-	fmt.Println()
+	return appSettings.Log.File, nil
 }
 
-func handleCursorProcesses(display *ui.Display, processManager *process.Manager) error {
-	if os.Getenv("AUTOMATED_MODE") == "1" {
-		log.Debug("Running in automated mode, skipping Cursor process closing")
+// openLogFile opens the log file, or returns nil if it is turned off or
+// cannot be opened
+func openLogFile() *logfile.File {
+	path, err := logFilePath()
+	if err != nil || path == "" {
+		if err != nil {
+			log.Warn("Log file disabled: ", err)
+		}
 		return nil
 	}
-
-	display.ShowProgress("Closing Cursor...")
-	log.Debug("Attempting to close Cursor processes")
-
-	if err := processManager.KillCursorProcesses(); err != nil {
-		log.Error("Failed to close Cursor:", err)
-		display.StopProgress()
-		display.ShowError("Failed to close Cursor. Please close it manually and try again.")
-		waitExit()
-		return err
-	}
-
-	if processManager.IsCursorRunning() {
-		log.Error("Cursor processes still detected after closing")
-		display.StopProgress()
-		display.ShowError("Failed to close Cursor completely. Please close it manually and try again.")
-		waitExit()
-		return fmt.Errorf("cursor still running")
-	}
-
-	log.Debug("Successfully closed all Cursor processes")
-	display.StopProgress()
-	fmt.Println()
-	return nil
-}
-
-func readExistingConfig(display *ui.Display, configManager *config.Manager, text lang.TextResource) *config.StorageConfig {
-	fmt.Println()
-	display.ShowProgress(text.ReadingConfig)
-	oldConfig, err := configManager.ReadConfig()
+	file, err := logfile.Open(path, int64(appSettings.Log.MaxSizeMB)<<20, appSettings.Log.MaxFiles, getCurrentUser())
 	if err != nil {
-		log.Warn("Failed to read existing config:", err)
-		oldConfig = nil
+		log.Warn("Log file disabled: ", err)
+		return nil
 	}
-	display.StopProgress()
-	fmt.Println()
-	return oldConfig
+	return file
 }
 
-func generateNewConfig(display *ui.Display, generator *idgen.Generator, oldConfig *config.StorageConfig, text lang.TextResource) *config.StorageConfig {
-	display.ShowProgress(text.GeneratingIds)
-	newConfig := &config.StorageConfig{}
-
-	if machineID, err := generator.GenerateMachineID(); err != nil {
-		log.Fatal("Failed to generate machine ID:", err)
-	} else {
-		newConfig.TelemetryMachineId = machineID
-	}
-
-	if macMachineID, err := generator.GenerateMacMachineID(); err != nil {
-		log.Fatal("Failed to generate MAC machine ID:", err)
-	} else {
-		newConfig.TelemetryMacMachineId = macMachineID
+func setupRedactor() {
+	mode := redact.DefaultMode(ui.IsTerminal(os.Stdout))
+	if appSettings.Redact != "" {
+		parsed, err := redact.ParseMode(appSettings.Redact)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		mode = parsed
 	}
+	redactor = redact.New(mode, nil)
+}
 
-	if deviceID, err := generator.GenerateDeviceID(); err != nil {
-		log.Fatal("Failed to generate device ID:", err)
-	} else {
-		newConfig.TelemetryDevDeviceId = deviceID
+func getCurrentUser() string {
+	if username := os.Getenv("SUDO_USER"); username != "" {
+		return username
 	}
 
-	if oldConfig != nil && oldConfig.TelemetrySqmId != "" {
-		newConfig.TelemetrySqmId = oldConfig.TelemetrySqmId
-	} else if sqmID, err := generator.GenerateSQMID(); err != nil {
-		log.Fatal("Failed to generate SQM ID:", err)
-	} else {
-		newConfig.TelemetrySqmId = sqmID
+	user, err := user.Current()
+	if err != nil {
+		log.Fatal(err)
 	}
-
-	display.StopProgress()
-	fmt.Println()
-	return newConfig
+	return user.Username
 }
 
-func saveConfiguration(display *ui.Display, configManager *config.Manager, newConfig *config.StorageConfig) error {
-	display.ShowProgress("Saving configuration...")
-	if err := configManager.SaveConfig(newConfig, *setReadOnly); err != nil {
-		log.Error(err)
-		waitExit()
-		return err
+func initConfigManager(username string) *config.Manager {
+	configManager, err := newConfigManager(username)
+	if err != nil {
+		log.Fatal(err)
 	}
-	display.StopProgress()
-	fmt.Println()
-	return nil
+	return configManager
 }
 
-func showCompletionMessages(display *ui.Display) {
-	display.ShowSuccess(lang.GetText().SuccessMessage, lang.GetText().RestartMessage)
-	fmt.Println()
-
-	message := "Operation completed!"
-	if lang.GetCurrentLanguage() == lang.CN {
-		message = "操作完成！"
+// newConfigManager creates the config manager for username, removing its
+// temporary file on interrupt
+func newConfigManager(username string) (*config.Manager, error) {
+	configManager, err := config.NewManager(username)
+	if err != nil {
+		return nil, err
 	}
-	display.ShowInfo(message)
+	removeTempOnInterrupt(configManager)
+	return configManager, nil
 }
 
 func waitExit() {
-	fmt.Print(lang.GetText().PressEnterToExit)
+	if !pauseOnExit {
+		return
+	}
+	fmt.Print(lang.T("PressEnterToExit"))
 	os.Stdout.Sync()
 	bufio.NewReader(os.Stdin).ReadString('\n')
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/internal/process"
+)
+
+// processesResult is the JSON form of the processes command
+type processesResult struct {
+	Processes []process.Candidate `json:"processes"`
+	Excluded  []process.Candidate `json:"excluded"`
+}
+
+// runProcesses implements the processes command, which lists the Cursor
+// processes the tool would close and the near misses it leaves alone
+func runProcesses(ctx context.Context, args []string) error {
+	fs := newFlagSet("processes")
+	asJSON := fs.Bool("json", jsonOutput(), "print the result as JSON")
+	kill := fs.Bool("kill", false, "close the processes and report the outcome for each")
+	fs.Parse(args)
+
+	if *kill {
+		return killProcesses(ctx, *asJSON)
+	}
+
+	candidates, err := newProcessManager().Candidates(ctx)
+	if err != nil {
+		return err
+	}
+	result := processesResult{Processes: []process.Candidate{}, Excluded: []process.Candidate{}}
+	for _, c := range candidates {
+		if c.Excluded == "" {
+			result.Processes = append(result.Processes, c)
+		} else {
+			result.Excluded = append(result.Excluded, c)
+		}
+	}
+
+	if *asJSON {
+		return printJSON(result)
+	}
+
+	if len(result.Processes) == 0 {
+		fmt.Println(lang.T("NoCursorProcesses"))
+	} else {
+		printCandidates(result.Processes, false)
+	}
+	if len(result.Excluded) > 0 {
+		fmt.Println("\n" + lang.T("ProcessesExcluded"))
+		printCandidates(result.Excluded, true)
+	}
+	return nil
+}
+
+// printCandidates prints candidates as a table, with the exclusion reason
+// when withReason is set
+func printCandidates(candidates []process.Candidate, withReason bool) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	header := "PID\tPPID\tUSER\tPATTERN\tEXECUTABLE"
+	if withReason {
+		header += "\tREASON"
+	}
+	fmt.Fprintln(w, header)
+	for _, c := range candidates {
+		row := fmt.Sprintf("%s\t%s\t%s\t%q\t%s", c.PID, c.PPID, c.User, c.Pattern, c.Executable)
+		if withReason {
+			row += "\t" + c.Excluded
+		}
+		fmt.Fprintln(w, row)
+	}
+	w.Flush()
+}
+
+// killProcesses closes Cursor and prints the outcome for every process
+func killProcesses(ctx context.Context, asJSON bool) error {
+	result, err := newProcessManager().KillCursorProcesses(ctx)
+	if result == nil || (err != nil && !errors.Is(err, process.ErrStillRunning)) {
+		return err
+	}
+
+	if asJSON {
+		if result.Processes == nil {
+			result.Processes = []process.KillStatus{}
+		}
+		if jsonErr := printJSON(result); jsonErr != nil {
+			return jsonErr
+		}
+		if err != nil {
+			return errSilent
+		}
+		return nil
+	}
+
+	if len(result.Processes) == 0 {
+		fmt.Println(lang.T("NoCursorProcesses"))
+		return nil
+	}
+	for _, p := range result.Processes {
+		line := fmt.Sprintf("%-8s %-18s %s", p.PID, p.Outcome, p.Signal)
+		if p.Error != "" {
+			line += ": " + p.Error
+		}
+		fmt.Println(strings.TrimRight(line, " "))
+	}
+	return err
+}
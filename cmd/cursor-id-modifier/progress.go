@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/yuaotian/go-cursor-help/internal/progress"
+	"github.com/yuaotian/go-cursor-help/internal/ui"
+)
+
+// newReporter creates a step reporter that shows progress on display and
+// logs it, and with asJSON also prints each event to stdout
+func newReporter(display *ui.Display, asJSON bool) *progress.Reporter {
+	reporter := progress.NewReporter(progress.NewLogListener(log), displayListener(display))
+	if asJSON {
+		reporter.Subscribe(progress.NewJSONListener(os.Stdout))
+	}
+	return reporter
+}
+
+// displayListener shows the running step with the display's spinner
+func displayListener(display *ui.Display) progress.Listener {
+	return progress.ListenerFunc(func(e progress.Event) {
+		switch e.Status {
+		case progress.StatusStarted, progress.StatusProgress:
+			display.ShowProgress(e.Message)
+		default:
+			display.StopProgress()
+			// End the spinner line; without a terminal it was printed whole
+			if display.IsTerminal() {
+				fmt.Fprintln(display.Writer())
+			}
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/yuaotian/go-cursor-help/internal/progress"
+	"github.com/yuaotian/go-cursor-help/internal/ui"
+)
+
+// openPTY returns the controlling and terminal ends of a new pseudo-terminal
+func openPTY(t *testing.T) (ptm, pts *os.File) {
+	t.Helper()
+	ptm, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	t.Cleanup(func() { ptm.Close() })
+	if err := unix.IoctlSetPointerInt(int(ptm.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatalf("failed to unlock pseudo-terminal: %v", err)
+	}
+	n, err := unix.IoctlGetInt(int(ptm.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Fatalf("failed to get pseudo-terminal number: %v", err)
+	}
+	pts, err = os.OpenFile("/dev/pts/"+strconv.Itoa(n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ptm, pts
+}
+
+func TestDisplayListenerOnTerminal(t *testing.T) {
+	ptm, pts := openPTY(t)
+	display := ui.NewDisplay(nil, pts)
+	if !display.IsTerminal() {
+		t.Fatal("IsTerminal = false for a pseudo-terminal")
+	}
+
+	runSteps(progress.NewReporter(displayListener(display)))
+	pts.Close()
+
+	// Reading the other end fails once the output is drained
+	data, err := io.ReadAll(ptm)
+	if err != nil && !errors.Is(err, syscall.EIO) {
+		t.Fatal(err)
+	}
+	out := string(data)
+
+	// Each step is drawn in place and its line ended once it finishes; the
+	// terminal turns each newline into \r\n
+	if got := strings.Count(out, "\n"); got != 2 {
+		t.Errorf("output has %d line ends, want 2: %q", got, out)
+	}
+	if !strings.HasSuffix(out, "\r\r\n") {
+		t.Errorf("output = %q, want it to end the spinner line", out)
+	}
+	for _, message := range []string{"Detecting", "Backing up"} {
+		if !strings.Contains(out, message) {
+			t.Errorf("output = %q, want it to show %q", out, message)
+		}
+	}
+}
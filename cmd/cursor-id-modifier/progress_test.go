@@ -0,0 +1,28 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/yuaotian/go-cursor-help/internal/progress"
+	"github.com/yuaotian/go-cursor-help/internal/ui"
+)
+
+// runSteps reports a detect step that succeeds and a backup step that fails
+func runSteps(reporter *progress.Reporter) {
+	run := reporter.Start(progress.StepDetect, "Detecting")
+	run.Update("Still detecting")
+	run.Done()
+	reporter.Start(progress.StepBackup, "Backing up").Fail(errors.New("disk full"))
+}
+
+func TestDisplayListenerWithoutTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	runSteps(progress.NewReporter(displayListener(ui.NewDisplay(nil, &buf))))
+
+	// Each message is a whole line, with no blank line after a step
+	if want := "Detecting\nStill detecting\nBacking up\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
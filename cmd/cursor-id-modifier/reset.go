@@ -0,0 +1,425 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/yuaotian/go-cursor-help/internal/config"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/internal/process"
+	"github.com/yuaotian/go-cursor-help/internal/progress"
+	"github.com/yuaotian/go-cursor-help/internal/ui"
+	"github.com/yuaotian/go-cursor-help/pkg/idgen"
+)
+
+// runReset implements the reset command: it closes Cursor and replaces
+// the identifiers in storage.json with newly generated ones
+func runReset(ctx context.Context, args []string) error {
+	fs := newFlagSet("reset")
+	readOnly := fs.Bool("r", false, "set storage.json to read-only mode")
+	restart := fs.Bool("restart", false, "start Cursor again after a successful reset")
+	wait := fs.Bool("wait", false, "wait for Cursor to be closed by the user instead of closing it")
+	waitTimeout := fs.Duration("timeout", 5*time.Minute, "with -wait, how long to wait for Cursor to close (0 waits indefinitely)")
+	noKill := fs.Bool("no-kill", false, "fail if Cursor is running instead of closing it")
+	asJSON := fs.Bool("json", jsonOutput(), "print the progress of each step as JSON Lines, with messages on stderr")
+	fs.Parse(args)
+
+	mode := closeKill
+	switch {
+	case *wait && *noKill:
+		return fmt.Errorf("-wait and -no-kill cannot be used together")
+	case *wait:
+		mode = closeWait
+	case *noKill:
+		mode = closeNone
+	}
+
+	// Initialize components
+	display := newDisplay()
+	if *asJSON {
+		// Keep stdout for the events
+		display = newDisplayTo(os.Stderr)
+		pauseOnExit = false
+	}
+	reporter := newReporter(display, *asJSON)
+	generator := idgen.NewGenerator()
+	processManager := newProcessManager()
+
+	// Setup display
+	setupDisplay(display)
+
+	step := reporter.Start(progress.StepDetect, lang.T("DetectingConfig"))
+	username := getCurrentUser()
+	log.Debug("Running as user:", username)
+	configManager, err := newConfigManager(username)
+	step.End(err)
+	if err != nil {
+		log.Error(err)
+		waitExit()
+		return errSilent
+	}
+
+	// Check and handle privileges
+	step = reporter.Start(progress.StepPrivileges, lang.T("CheckingPrivileges"))
+	if err := handlePrivileges(display, step); err != nil {
+		return errSilent
+	}
+
+	// Handle Cursor processes
+	step = reporter.Start(progress.StepStop, lang.T("CheckingProcesses"))
+	if err := handleCursorProcesses(ctx, display, step, processManager, mode, *waitTimeout); err != nil {
+		return errSilent
+	}
+
+	// Handle configuration
+	step = reporter.Start(progress.StepBackup, lang.T("BackingUpConfig"))
+	backupPath, err := backupBeforeReset(display, step, configManager)
+	if err != nil {
+		return errSilent
+	}
+
+	step = reporter.Start(progress.StepGenerate, lang.T("GeneratingIds"))
+	oldConfig := readExistingConfig(configManager)
+	newConfig := generateNewConfig(generator, oldConfig)
+	step.Done()
+
+	lock := *readOnly || *setReadOnly
+	step = reporter.Start(progress.StepWrite, lang.T("SavingConfig"))
+	err = saveConfiguration(ctx, step, configManager, newConfig, lock)
+	recordAudit(username, "reset", configManager, oldConfig, newConfig, err)
+	if err != nil {
+		return errSilent
+	}
+
+	step = reporter.Start(progress.StepVerify, lang.T("VerifyingConfig"))
+	err = configManager.Verify(newConfig, backupPath, lock)
+	step.End(err)
+	if err != nil {
+		log.Error("Verification failed:", err)
+		display.ShowError(lang.T("VerifyFailed", lang.Args{"error": err}))
+		restoreAfterFailedVerify(ctx, display, reporter, configManager, username, backupPath)
+		waitExit()
+		return errSilent
+	}
+
+	restarted := *restart && restartCursor(display, processManager, username)
+
+	// Show completion messages
+	showCompletionMessages(display, restarted)
+	if lock {
+		display.ShowWarning(lang.T("SetReadOnlyMessage"))
+		display.ShowWarning(lang.T("UnlockHint", lang.Args{"program": programName()}))
+	}
+
+	if os.Getenv("AUTOMATED_MODE") != "1" {
+		waitExit()
+	}
+	return nil
+}
+
+func handlePrivileges(display *ui.Display, step *progress.Run) error {
+	isAdmin, err := checkAdminPrivileges()
+	if err != nil {
+		step.Fail(err)
+		log.Error(err)
+		waitExit()
+		return err
+	}
+
+	if !isAdmin {
+		if runtime.GOOS == "windows" {
+			// The elevated process runs the steps again
+			step.Skip("")
+			return handleWindowsPrivileges(display)
+		}
+		err := fmt.Errorf("insufficient privileges")
+		step.Fail(err)
+		display.ShowPrivilegeError(
+			lang.T("PrivilegeError"),
+			lang.T("RunWithSudo"),
+			sudoExample(),
+		)
+		waitExit()
+		return err
+	}
+	step.Done()
+	return nil
+}
+
+// sudoExample shows how to run this executable with sudo
+func sudoExample() string {
+	exe, _ := os.Executable()
+	return lang.T("SudoExample", lang.Args{"program": exe})
+}
+
+func handleWindowsPrivileges(display *ui.Display) error {
+	fmt.Fprintln(display.Writer(), lang.T("RequestingPrivileges"))
+
+	if err := selfElevate(); err != nil {
+		log.Error(err)
+		display.ShowPrivilegeError(
+			lang.T("PrivilegeError"),
+			lang.T("RunAsAdmin"),
+			lang.T("RunWithSudo"),
+			sudoExample(),
+		)
+		waitExit()
+		return err
+	}
+	return nil
+}
+
+func setupDisplay(display *ui.Display) {
+	if err := display.ClearScreen(); err != nil {
+		log.Warn("Failed to clear screen:", err)
+	}
+	display.ShowLogo()
+	fmt.Fprintln(display.Writer())
+}
+
+// closeMode selects how reset deals with a running Cursor
+type closeMode int
+
+const (
+	closeKill closeMode = iota // Close Cursor, forcefully if needed
+	closeWait                  // Wait for the user to close Cursor
+	closeNone                  // Fail if Cursor is running
+)
+
+func handleCursorProcesses(ctx context.Context, display *ui.Display, step *progress.Run, processManager *process.Manager, mode closeMode, waitTimeout time.Duration) error {
+	if os.Getenv("AUTOMATED_MODE") == "1" {
+		log.Debug("Running in automated mode, skipping Cursor process closing")
+		step.Skip("")
+		return nil
+	}
+
+	switch mode {
+	case closeNone:
+		if processManager.IsCursorRunning(ctx) {
+			err := fmt.Errorf("cursor is running")
+			step.Fail(err)
+			display.ShowError(lang.T("CursorRunning"))
+			waitExit()
+			return err
+		}
+		step.Done()
+		return nil
+	case closeWait:
+		return waitForCursorExit(ctx, display, step, processManager, waitTimeout)
+	}
+
+	step.Update(lang.T("ClosingProcesses"))
+	log.Debug("Attempting to close Cursor processes")
+
+	result, err := processManager.KillCursorProcesses(ctx)
+	step.End(err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		log.Error("Failed to close Cursor:", err)
+		if errors.Is(err, process.ErrStillRunning) {
+			display.ShowError(lang.T("CloseIncomplete"))
+			showKillSurvivors(display, result)
+		} else {
+			display.ShowError(lang.T("CloseFailed"))
+		}
+		waitExit()
+		return err
+	}
+
+	log.Debug("Successfully closed all Cursor processes")
+	if len(result.Processes) > 0 {
+		display.ShowInfo(lang.T("ProcessesClosed", lang.Args{"count": len(result.Processes)}))
+	}
+	return nil
+}
+
+// showKillSurvivors lists the processes that could not be closed and why
+func showKillSurvivors(display *ui.Display, result *process.KillResult) {
+	for _, p := range result.Survivors() {
+		if p.Outcome == process.OutcomePermissionDenied {
+			display.ShowWarning(lang.T("SurvivorPermissionDenied", lang.Args{"pid": p.PID}))
+		} else {
+			display.ShowWarning(lang.T("SurvivorStillRunning", lang.Args{"pid": p.PID, "signal": p.Signal}))
+		}
+	}
+}
+
+// waitForCursorExit shows the running Cursor processes until the user has
+// closed them all or the timeout expires
+func waitForCursorExit(ctx context.Context, display *ui.Display, step *progress.Run, processManager *process.Manager, timeout time.Duration) error {
+	log.Debug("Waiting for Cursor processes to exit")
+	err := processManager.WaitForExit(ctx, timeout, func(pids []string) {
+		step.Update(lang.T("WaitingForClose", lang.Args{"count": len(pids), "pids": summarizePIDs(pids, 5)}))
+	})
+	step.End(err)
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if errors.Is(err, process.ErrWaitTimeout) {
+			display.ShowError(lang.T("WaitTimeout", lang.Args{"timeout": timeout}))
+		} else {
+			log.Error("Failed to check Cursor processes:", err)
+		}
+		waitExit()
+		return err
+	}
+	return nil
+}
+
+// summarizePIDs joins up to max PIDs and notes how many were left out
+func summarizePIDs(pids []string, max int) string {
+	if len(pids) <= max {
+		return strings.Join(pids, ", ")
+	}
+	return lang.T("MorePIDs", lang.Args{"pids": strings.Join(pids[:max], ", "), "count": len(pids) - max})
+}
+
+// backupBeforeReset backs up storage.json, if there is one, so that the
+// reset can be undone, and returns the path of the backup
+func backupBeforeReset(display *ui.Display, step *progress.Run, configManager *config.Manager) (string, error) {
+	backupPath, err := backupConfig(configManager)
+	if err != nil {
+		step.Fail(err)
+		log.Error(err)
+		waitExit()
+		return "", err
+	}
+	if backupPath == "" {
+		step.Skip(lang.T("NoConfigToBackUp"))
+		return "", nil
+	}
+	step.Done()
+	display.ShowInfo(lang.T("BackupSaved", lang.Args{"path": backupPath}))
+	return backupPath, nil
+}
+
+func readExistingConfig(configManager *config.Manager) *config.StorageConfig {
+	oldConfig, err := configManager.ReadConfig()
+	if err != nil {
+		log.Warn("Failed to read existing config:", err)
+		oldConfig = nil
+	}
+	if oldConfig != nil {
+		redactor.Register(oldConfig.TelemetryMachineId, oldConfig.TelemetryMacMachineId,
+			oldConfig.TelemetryDevDeviceId, oldConfig.TelemetrySqmId)
+	}
+	return oldConfig
+}
+
+func generateNewConfig(generator *idgen.Generator, oldConfig *config.StorageConfig) *config.StorageConfig {
+	newConfig := &config.StorageConfig{}
+
+	if machineID, err := generator.GenerateMachineID(); err != nil {
+		log.Fatal("Failed to generate machine ID:", err)
+	} else {
+		newConfig.TelemetryMachineId = machineID
+	}
+
+	if macMachineID, err := generator.GenerateMacMachineID(); err != nil {
+		log.Fatal("Failed to generate MAC machine ID:", err)
+	} else {
+		newConfig.TelemetryMacMachineId = macMachineID
+	}
+
+	if deviceID, err := generator.GenerateDeviceID(); err != nil {
+		log.Fatal("Failed to generate device ID:", err)
+	} else {
+		newConfig.TelemetryDevDeviceId = deviceID
+	}
+
+	if oldConfig != nil && oldConfig.TelemetrySqmId != "" {
+		newConfig.TelemetrySqmId = oldConfig.TelemetrySqmId
+	} else if sqmID, err := generator.GenerateSQMID(); err != nil {
+		log.Fatal("Failed to generate SQM ID:", err)
+	} else {
+		newConfig.TelemetrySqmId = sqmID
+	}
+
+	redactor.Register(newConfig.TelemetryMachineId, newConfig.TelemetryMacMachineId,
+		newConfig.TelemetryDevDeviceId, newConfig.TelemetrySqmId)
+	log.Debugf("Generated identifiers: machineId=%s macMachineId=%s devDeviceId=%s sqmId=%s",
+		newConfig.TelemetryMachineId, newConfig.TelemetryMacMachineId,
+		newConfig.TelemetryDevDeviceId, newConfig.TelemetrySqmId)
+	return newConfig
+}
+
+func saveConfiguration(ctx context.Context, step *progress.Run, configManager *config.Manager, newConfig *config.StorageConfig, readOnly bool) error {
+	err := configManager.SaveConfig(ctx, newConfig, readOnly)
+	step.End(err)
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		log.Error(err)
+		waitExit()
+		return err
+	}
+	return nil
+}
+
+// restoreAfterFailedVerify puts back the backup taken before the reset
+func restoreAfterFailedVerify(ctx context.Context, display *ui.Display, reporter *progress.Reporter, configManager *config.Manager, username, backupPath string) {
+	if backupPath == "" {
+		display.ShowWarning(lang.T("NoBackupToRestore"))
+		return
+	}
+
+	step := reporter.Start(progress.StepRestore, lang.T("RestoringBackup"))
+	written, _ := configManager.ReadConfig()
+	err := configManager.RestoreBackup(ctx, backupPath, false)
+	restored, readErr := configManager.ReadConfig()
+	if err == nil && readErr != nil {
+		err = readErr
+	}
+	step.End(err)
+	if restored != nil {
+		recordAudit(username, "restore", configManager, written, restored, err)
+	}
+	if err != nil {
+		log.Error("Failed to restore backup:", err)
+		display.ShowError(lang.T("RestoreFailed", lang.Args{"path": backupPath, "program": programName()}))
+		return
+	}
+	display.ShowInfo(lang.T("BackupRestored", lang.Args{"path": backupPath}))
+}
+
+func showCompletionMessages(display *ui.Display, restarted bool) {
+	if restarted {
+		display.ShowSuccess(lang.T("SuccessMessage"))
+	} else {
+		display.ShowSuccess(lang.T("SuccessMessage"), lang.T("RestartMessage"))
+	}
+	fmt.Fprintln(display.Writer())
+
+	display.ShowInfo(lang.T("OperationCompleted"))
+}
+
+// restartCursor relaunches the Cursor instances that were closed before the
+// reset, as the user who invoked the tool, and reports whether it did
+func restartCursor(display *ui.Display, processManager *process.Manager, username string) bool {
+	targets := process.LaunchTargets(processManager.Closed())
+	if len(targets) == 0 {
+		log.Debug("Cursor was not running, nothing to restart")
+		return false
+	}
+
+	for _, target := range targets {
+		log.Debugf("Restarting %s %v in %s", target.Executable, target.Args, target.Dir)
+		if err := process.Launch(target, username); err != nil {
+			log.Error("Failed to restart Cursor:", err)
+			display.ShowWarning(lang.T("RestartFailed"))
+			return false
+		}
+	}
+	display.ShowInfo(lang.T("CursorRestarted"))
+	return true
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/yuaotian/go-cursor-help/internal/config"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/pkg/idgen"
+)
+
+// fieldIDTypes maps storage.json keys to the ID types validated by idgen
+var fieldIDTypes = map[string]string{
+	"telemetry.machineId":    "machineID",
+	"telemetry.macMachineId": "macMachineID",
+	"telemetry.devDeviceId":  "deviceID",
+	"telemetry.sqmId":        "sqmID",
+}
+
+// runSet implements the set subcommand, which writes a caller-supplied
+// value into a single identifier field
+func runSet(ctx context.Context, args []string) error {
+	fs := newFlagSet("set")
+	field := fs.String("field", "", "identifier to set: "+strings.Join(config.TelemetryKeys, ", "))
+	value := fs.String("value", "", "new value for the identifier")
+	readOnly := fs.Bool("r", false, "set storage.json to read-only mode")
+	fs.Parse(args)
+
+	if *field == "" || *value == "" {
+		fs.Usage()
+		return fmt.Errorf("both -field and -value are required")
+	}
+	if err := validateField(idgen.NewGenerator(), *field, *value); err != nil {
+		return err
+	}
+
+	username := getCurrentUser()
+	display := newDisplay()
+	configManager := initConfigManager(username)
+
+	if newProcessManager().IsCursorRunning(ctx) {
+		display.ShowError(lang.T("CursorRunning"))
+		return errSilent
+	}
+
+	oldConfig, err := configManager.ReadConfig()
+	if err != nil {
+		return err
+	}
+	var oldValue string
+	newConfig := &config.StorageConfig{}
+	if oldConfig != nil {
+		oldValue, _ = oldConfig.Get(*field)
+		*newConfig = *oldConfig
+	}
+	newConfig.Set(*field, *value)
+	redactor.Register(oldValue, *value)
+
+	err = configManager.SetField(ctx, *field, *value, *readOnly)
+	recordAudit(username, "set", configManager, oldConfig, newConfig, err)
+	if err != nil {
+		return err
+	}
+
+	display.ShowSuccess(lang.T("FieldUpdated", lang.Args{"field": *field}))
+	display.ShowIdentifier(lang.T("OldValue"), oldValue)
+	display.ShowIdentifier(lang.T("NewValue"), *value)
+	return nil
+}
+
+// validateField checks value against the idgen format rules for field
+func validateField(generator *idgen.Generator, field, value string) error {
+	idType, ok := fieldIDTypes[field]
+	if !ok {
+		return fmt.Errorf("unknown field %q: expected one of %s", field, strings.Join(config.TelemetryKeys, ", "))
+	}
+	if !generator.ValidateID(value, idType) {
+		return fmt.Errorf("invalid value for %s: not a valid %s", field, idType)
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/fatih/color"
+
+	"github.com/yuaotian/go-cursor-help/internal/config"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/internal/process"
+	"github.com/yuaotian/go-cursor-help/internal/ui"
+	"github.com/yuaotian/go-cursor-help/pkg/idgen"
+)
+
+// tuiView is the page shown by the interactive mode
+type tuiView int
+
+const (
+	tuiMain    tuiView = iota // State overview and field selection
+	tuiPreview                // Diff of the selected fields, awaiting confirmation
+)
+
+// tuiMaxBackups is how many of the newest backups are listed
+const tuiMaxBackups = 5
+
+// Styles of the interactive mode
+var (
+	tuiTitle   = color.New(color.Bold).SprintFunc()
+	tuiHeading = color.New(color.FgCyan, color.Bold).SprintFunc()
+	tuiDim     = color.New(color.Faint).SprintFunc()
+)
+
+// tuiField is an identifier that can be selected for regeneration
+type tuiField struct {
+	key      string
+	current  string
+	next     string // Generated when the preview opens
+	selected bool
+}
+
+// tui is the state of the interactive mode
+type tui struct {
+	ctx            context.Context
+	username       string
+	configManager  *config.Manager
+	processManager *process.Manager
+	generator      *idgen.Generator
+
+	profiles  []config.Profile
+	current   *config.StorageConfig
+	fields    []tuiField
+	processes []process.Candidate
+	backups   []string
+
+	view      tuiView
+	cursor    int
+	status    string
+	statusErr bool
+}
+
+// runTUI implements the tui command, a full-screen view of the profiles,
+// identifiers, Cursor processes and backups from which selected
+// identifiers are regenerated after previewing the change
+func runTUI(ctx context.Context, args []string) error {
+	fs := newFlagSet("tui")
+	fs.Parse(args)
+
+	username := getCurrentUser()
+	display := newDisplay()
+	t := &tui{
+		ctx:            ctx,
+		username:       username,
+		configManager:  initConfigManager(username),
+		processManager: newProcessManager(),
+		generator:      idgen.NewGenerator(),
+	}
+	if err := t.refresh(); err != nil {
+		return err
+	}
+
+	screen, err := display.OpenScreen()
+	if errors.Is(err, ui.ErrNotTerminal) {
+		display.ShowError(lang.T("TUINeedsTerminal"))
+		return errSilent
+	} else if err != nil {
+		return err
+	}
+	onInterrupt(func() { screen.Close() })
+	defer screen.Close()
+
+	for {
+		screen.Draw(t.render(screen))
+		key, err := screen.ReadKey()
+		if err != nil {
+			return fmt.Errorf("failed to read key: %w", err)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if quit := t.handle(key); quit {
+			return nil
+		}
+	}
+}
+
+// refresh reloads everything shown, keeping the selected fields
+func (t *tui) refresh() error {
+	profiles, err := config.Profiles(t.username)
+	if err != nil {
+		return err
+	}
+	t.profiles = profiles
+
+	if t.current, err = t.configManager.ReadConfig(); err != nil {
+		return err
+	}
+	selected := make(map[string]bool)
+	for _, f := range t.fields {
+		selected[f.key] = f.selected
+	}
+	t.fields = nil
+	for _, key := range config.TelemetryKeys {
+		var value string
+		if t.current != nil {
+			value, _ = t.current.Get(key)
+		}
+		redactor.Register(value)
+		isSelected, known := selected[key]
+		if !known {
+			// Like reset, keep an existing sqmId unless asked otherwise
+			isSelected = key != "telemetry.sqmId" || value == ""
+		}
+		t.fields = append(t.fields, tuiField{key: key, current: value, selected: isSelected})
+	}
+
+	candidates, err := t.processManager.Candidates(t.ctx)
+	if err != nil {
+		return err
+	}
+	t.processes = nil
+	for _, c := range candidates {
+		if c.Excluded == "" {
+			t.processes = append(t.processes, c)
+		}
+	}
+
+	t.backups, err = t.configManager.ListBackups()
+	return err
+}
+
+// handle applies a key press and reports whether to quit
+func (t *tui) handle(key ui.Keypress) bool {
+	if key.Key == ui.KeyInterrupt || key.Is('q') {
+		return true
+	}
+	t.status, t.statusErr = "", false
+
+	if t.view == tuiPreview {
+		switch {
+		case key.Key == ui.KeyEnter || key.Is('y'):
+			t.apply()
+			t.view = tuiMain
+		case key.Key == ui.KeyEscape || key.Key == ui.KeyBackspace || key.Is('n'):
+			t.setStatus(lang.T("TUICancelled"), false)
+			t.view = tuiMain
+		}
+		return false
+	}
+
+	switch {
+	case key.Key == ui.KeyEscape:
+		return true
+	case key.Key == ui.KeyUp || key.Is('k'):
+		if t.cursor > 0 {
+			t.cursor--
+		}
+	case key.Key == ui.KeyDown || key.Is('j'):
+		if t.cursor < len(t.fields)-1 {
+			t.cursor++
+		}
+	case key.Key == ui.KeyHome:
+		t.cursor = 0
+	case key.Key == ui.KeyEnd:
+		t.cursor = len(t.fields) - 1
+	case key.Key == ui.KeySpace || key.Is('x'):
+		t.fields[t.cursor].selected = !t.fields[t.cursor].selected
+	case key.Is('a'):
+		all := true
+		for _, f := range t.fields {
+			all = all && f.selected
+		}
+		for i := range t.fields {
+			t.fields[i].selected = !all
+		}
+	case key.Is('r'):
+		if err := t.refresh(); err != nil {
+			t.setStatus(err.Error(), true)
+		} else {
+			t.setStatus(lang.T("TUIRefreshed"), false)
+		}
+	case key.Key == ui.KeyEnter || key.Is('p'):
+		t.preview()
+	}
+	return false
+}
+
+// preview generates new values for the selected fields and shows the diff
+func (t *tui) preview() {
+	selected := 0
+	for i := range t.fields {
+		if !t.fields[i].selected {
+			continue
+		}
+		value, err := generateField(t.generator, t.fields[i].key)
+		if err != nil {
+			t.setStatus(err.Error(), true)
+			return
+		}
+		redactor.Register(value)
+		t.fields[i].next = value
+		selected++
+	}
+	if selected == 0 {
+		t.setStatus(lang.T("TUISelectIdentifier"), true)
+		return
+	}
+	t.view = tuiPreview
+}
+
+// apply backs up storage.json and writes the previewed values
+func (t *tui) apply() {
+	if t.processManager.IsCursorRunning(t.ctx) {
+		t.setStatus(lang.T("TUICursorRunning"), true)
+		return
+	}
+
+	updates := make(map[string]string)
+	newConfig := &config.StorageConfig{}
+	if t.current != nil {
+		*newConfig = *t.current
+	}
+	for _, f := range t.fields {
+		if f.selected {
+			updates[f.key] = f.next
+			newConfig.Set(f.key, f.next)
+		}
+	}
+
+	backupPath, err := backupConfig(t.configManager)
+	if err != nil {
+		t.setStatus(lang.T("TUIBackupFailed", lang.Args{"error": err}), true)
+		return
+	}
+	err = t.configManager.SetFields(t.ctx, updates, false)
+	recordAudit(t.username, "tui", t.configManager, t.current, newConfig, err)
+	if err != nil {
+		t.setStatus(err.Error(), true)
+		return
+	}
+
+	message := lang.T("TUIRegenerated", lang.Args{lang.CountArg: len(updates)})
+	if backupPath != "" {
+		message = lang.T("TUIRegeneratedWithBackup", lang.Args{lang.CountArg: len(updates), "name": filepath.Base(backupPath)})
+	}
+	if err := t.refresh(); err != nil {
+		message += " " + lang.T("TUIRefreshFailed", lang.Args{"error": err})
+	}
+	t.setStatus(message, false)
+}
+
+func (t *tui) setStatus(message string, isErr bool) {
+	t.status, t.statusErr = message, isErr
+}
+
+// render lays out the current view for screen
+func (t *tui) render(screen *ui.Screen) []string {
+	width, _ := screen.Size()
+	line := func(s string) string { return ui.Truncate(s, width) }
+
+	lines := []string{tuiTitle(line(" " + lang.T("TUITitle", lang.Args{"program": programName(), "version": version}))), ""}
+	if t.view == tuiPreview {
+		lines = append(lines, t.renderPreview(screen, line)...)
+	} else {
+		lines = append(lines, t.renderMain(screen, line)...)
+	}
+
+	lines = append(lines, "")
+	if t.status != "" {
+		statusColor := color.New(color.FgGreen).Sprint
+		if t.statusErr {
+			statusColor = color.New(color.FgRed).Sprint
+		}
+		lines = append(lines, statusColor(line(" "+t.status)))
+	}
+	help := lang.T("TUIHelp")
+	if t.view == tuiPreview {
+		help = lang.T("TUIPreviewHelp")
+	}
+	return append(lines, tuiDim(line(" "+help)))
+}
+
+func (t *tui) renderMain(screen *ui.Screen, line func(string) string) []string {
+	lines := []string{tuiHeading(" " + lang.T("TUIProfiles"))}
+	for _, p := range t.profiles {
+		marker, note := "○", ""
+		if p.Active {
+			marker = "●"
+		}
+		if !p.Exists {
+			note = " " + lang.T("TUIProfileNotFound")
+		}
+		lines = append(lines, line(fmt.Sprintf("   %s %-16s %s%s", marker, p.Name, p.Path, note)))
+	}
+
+	lines = append(lines, "", tuiHeading(" "+lang.T("TUIIdentifiers")))
+	for i, f := range t.fields {
+		pointer, box := " ", "[ ]"
+		if i == t.cursor {
+			pointer = ">"
+		}
+		if f.selected {
+			box = "[x]"
+		}
+		lines = append(lines, line(fmt.Sprintf(" %s %s %-24s %s", pointer, box, f.key, orDash(screen.Redact(f.current)))))
+	}
+
+	lines = append(lines, "", tuiHeading(" "+lang.T("TUIProcesses")))
+	if len(t.processes) == 0 {
+		lines = append(lines, tuiDim("   "+lang.T("TUINoneRunning")))
+	}
+	for _, p := range t.processes {
+		lines = append(lines, line(fmt.Sprintf("   %-8s %-12s %s", p.PID, p.User, p.Executable)))
+	}
+
+	lines = append(lines, "", tuiHeading(line(" "+lang.T("TUIBackups", lang.Args{lang.CountArg: len(t.backups), "dir": t.configManager.BackupDir()}))))
+	if len(t.backups) == 0 {
+		lines = append(lines, tuiDim("   "+lang.T("TUINoBackups")))
+	}
+	for i, b := range t.backups {
+		if i == tuiMaxBackups {
+			lines = append(lines, tuiDim("   "+lang.T("TUIOlderBackups", lang.Args{lang.CountArg: len(t.backups) - tuiMaxBackups})))
+			break
+		}
+		lines = append(lines, line("   "+filepath.Base(b)))
+	}
+	return lines
+}
+
+func (t *tui) renderPreview(screen *ui.Screen, line func(string) string) []string {
+	lines := []string{
+		tuiHeading(" " + lang.T("TUIPreview")),
+		line(" --- " + t.configManager.ConfigPath()),
+		line(" +++ " + lang.T("TUIPreviewAfter")),
+	}
+	for _, f := range t.fields {
+		if f.selected {
+			lines = append(lines,
+				line(" ~ "+f.key),
+				color.New(color.FgRed).Sprint(line("   - "+orDash(screen.Redact(f.current)))),
+				color.New(color.FgGreen).Sprint(line("   + "+screen.Redact(f.next))))
+		}
+	}
+	lines = append(lines, "", line(" "+lang.T("TUIBackedUpFirst")))
+	if len(t.processes) > 0 {
+		lines = append(lines, color.New(color.FgYellow).Sprint(line(" "+lang.T("TUICloseBeforeApplying"))))
+	}
+	return lines
+}
+
+// generateField returns a new random value for a storage.json identifier
+func generateField(generator *idgen.Generator, key string) (string, error) {
+	switch key {
+	case "telemetry.machineId":
+		return generator.GenerateMachineID()
+	case "telemetry.macMachineId":
+		return generator.GenerateMacMachineID()
+	case "telemetry.devDeviceId":
+		return generator.GenerateDeviceID()
+	case "telemetry.sqmId":
+		return generator.GenerateSQMID()
+	}
+	return "", fmt.Errorf("unknown field %q", key)
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+)
+
+// runUnlock implements the unlock command, which undoes -r by restoring
+// the mode storage.json had before it was made read-only
+func runUnlock(ctx context.Context, args []string) error {
+	fs := newFlagSet("unlock")
+	fs.Parse(args)
+
+	display := newDisplay()
+	configManager := initConfigManager(getCurrentUser())
+
+	readOnly, err := configManager.IsReadOnly()
+	if err != nil {
+		return err
+	}
+	record, err := configManager.LockRecord()
+	if err != nil {
+		return err
+	}
+	if !readOnly && record == nil {
+		display.ShowInfo(lang.T("NotReadOnly"))
+		return nil
+	}
+
+	mode, err := configManager.Unlock()
+	if err != nil {
+		return err
+	}
+	if record == nil {
+		display.ShowWarning(lang.T("NoLockRecord", lang.Args{"mode": mode}))
+	}
+	display.ShowSuccess(lang.T("Unlocked", lang.Args{"mode": mode}))
+	return nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// runVersion implements the version command
+func runVersion(ctx context.Context, args []string) error {
+	fs := newFlagSet("version")
+	fs.Parse(args)
+	printVersion()
+	return nil
+}
+
+func printVersion() {
+	fmt.Printf("Cursor ID Modifier v%s\n", version)
+	fmt.Printf("%s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -4,12 +4,12 @@ go 1.21
 
 require (
 	github.com/fatih/color v1.15.0
+	github.com/mattn/go-isatty v0.0.17
 	github.com/sirupsen/logrus v1.9.3
+	golang.org/x/sys v0.13.0
 )
 
 require (
 	github.com/mattn/go-colorable v0.1.13 // indirect
-	github.com/mattn/go-isatty v0.0.17 // indirect
 	github.com/stretchr/testify v1.10.0 // indirect
-	golang.org/x/sys v0.13.0 // indirect
 )
@@ -0,0 +1,195 @@
+// Package audit keeps an append-only record of identifier changes
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/yuaotian/go-cursor-help/internal/paths"
+)
+
+// FileName is the name of the audit log inside the state directory
+const FileName = "audit.jsonl"
+
+// Result values recorded for an entry
+const (
+	ResultSuccess = "success"
+	ResultFailure = "failure"
+)
+
+// hashPrefix marks values that were hashed before being recorded
+const hashPrefix = "sha256:"
+
+// FieldChange records the old and new value of a single identifier
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old,omitempty"`
+	New   string `json:"new"`
+}
+
+// Entry is a single line of the audit log
+type Entry struct {
+	Time          time.Time     `json:"time"`
+	User          string        `json:"user"`
+	EffectiveUser string        `json:"effectiveUser,omitempty"`
+	Action        string        `json:"action"`
+	ConfigPath    string        `json:"configPath"`
+	Changes       []FieldChange `json:"changes"`
+	Hashed        bool          `json:"hashed,omitempty"`
+	Version       string        `json:"version"`
+	Result        string        `json:"result"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// Filter selects entries when querying the log
+type Filter struct {
+	Since time.Time // Zero means no lower bound
+	Until time.Time // Zero means no upper bound
+	Field string    // Only entries changing this field; empty matches all
+}
+
+// Log is an append-only JSON Lines audit log
+type Log struct {
+	path       string
+	username   string
+	hashValues bool
+	mu         sync.Mutex
+}
+
+// NewLog opens the audit log in the state directory of username.
+// When hashValues is set, identifier values are stored as SHA-256 hashes.
+func NewLog(username string, hashValues bool) (*Log, error) {
+	dir, err := paths.StateDir(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state directory: %w", err)
+	}
+	return &Log{
+		path:       filepath.Join(dir, FileName),
+		username:   username,
+		hashValues: hashValues,
+	}, nil
+}
+
+// Path returns the location of the log file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Append writes an entry to the end of the log. Missing timestamp and
+// effective user are filled in.
+func (l *Log) Append(entry Entry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	if entry.EffectiveUser == "" {
+		if u, err := user.Current(); err == nil {
+			entry.EffectiveUser = u.Username
+		}
+	}
+	if l.hashValues && !entry.Hashed {
+		changes := make([]FieldChange, len(entry.Changes))
+		for i, c := range entry.Changes {
+			changes[i] = FieldChange{Field: c.Field, Old: HashValue(c.Old), New: HashValue(c.New)}
+		}
+		entry.Changes = changes
+		entry.Hashed = true
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit entry: %w", err)
+	}
+
+	if err := paths.EnsureDir(filepath.Dir(l.path), l.username); err != nil {
+		return err
+	}
+	_, statErr := os.Stat(l.path)
+
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	if os.IsNotExist(statErr) {
+		paths.ChownToUser(l.path, l.username)
+	}
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return file.Sync()
+}
+
+// Query returns the entries matching filter in the order they were written
+func (l *Log) Query(filter Filter) ([]Entry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid audit entry on line %d: %w", lineNo, err)
+		}
+		if filter.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// HashValue returns the SHA-256 form in which hashed values are recorded
+func HashValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return hashPrefix + hex.EncodeToString(sum[:])
+}
+
+func (f Filter) matches(entry Entry) bool {
+	if !f.Since.IsZero() && entry.Time.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !entry.Time.Before(f.Until) {
+		return false
+	}
+	if f.Field == "" {
+		return true
+	}
+	for _, c := range entry.Changes {
+		if c.Field == f.Field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,113 @@
+package audit
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLog(t *testing.T, hashValues bool) *Log {
+	t.Helper()
+	u, err := user.Current()
+	if err != nil {
+		t.Fatalf("user.Current: %v", err)
+	}
+	return &Log{
+		path:       filepath.Join(t.TempDir(), "state", FileName),
+		username:   u.Username,
+		hashValues: hashValues,
+	}
+}
+
+func TestQueryFilters(t *testing.T) {
+	log := newTestLog(t, false)
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, field := range []string{"telemetry.machineId", "telemetry.devDeviceId", "telemetry.machineId"} {
+		entry := Entry{
+			Time:    base.Add(time.Duration(i) * time.Hour),
+			User:    "alice",
+			Action:  "reset",
+			Changes: []FieldChange{{Field: field, Old: "old", New: "new"}},
+			Result:  ResultSuccess,
+		}
+		if err := log.Append(entry); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter Filter
+		want   []int // Hours after base
+	}{
+		{"no filter", Filter{}, []int{0, 1, 2}},
+		{"since is inclusive", Filter{Since: base.Add(time.Hour)}, []int{1, 2}},
+		{"until is exclusive", Filter{Until: base.Add(time.Hour)}, []int{0}},
+		{"range", Filter{Since: base.Add(30 * time.Minute), Until: base.Add(90 * time.Minute)}, []int{1}},
+		{"field", Filter{Field: "telemetry.machineId"}, []int{0, 2}},
+		{"field and range", Filter{Field: "telemetry.machineId", Since: base.Add(time.Minute)}, []int{2}},
+		{"unknown field", Filter{Field: "telemetry.sqmId"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := log.Query(tt.filter)
+			if err != nil {
+				t.Fatalf("Query: %v", err)
+			}
+			var got []int
+			for _, e := range entries {
+				got = append(got, int(e.Time.Sub(base)/time.Hour))
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got entries %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got entries %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryMissingLog(t *testing.T) {
+	entries, err := newTestLog(t, false).Query(Filter{})
+	if err != nil || entries != nil {
+		t.Errorf("Query = %v, %v; want no entries and no error", entries, err)
+	}
+}
+
+func TestQueryInvalidLine(t *testing.T) {
+	log := newTestLog(t, false)
+	if err := log.Append(Entry{Action: "reset"}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	file, err := os.OpenFile(log.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString("\n{not json\n")
+	file.Close()
+
+	if _, err := log.Query(Filter{}); err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Query error = %v, want one naming line 3", err)
+	}
+}
+
+func TestAppendHashesValues(t *testing.T) {
+	log := newTestLog(t, true)
+	if err := log.Append(Entry{Changes: []FieldChange{{Field: "telemetry.machineId", Old: "", New: "secret"}}}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	entries, err := log.Query(Filter{})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Query = %v, %v", entries, err)
+	}
+	c := entries[0].Changes[0]
+	if !entries[0].Hashed || c.New != HashValue("secret") || c.Old != "" {
+		t.Errorf("got %+v, hashed=%v", c, entries[0].Hashed)
+	}
+}
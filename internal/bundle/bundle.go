@@ -0,0 +1,223 @@
+// Package bundle reads and writes identity bundles, the files used to move
+// a Cursor identity from one machine to another.
+//
+// A bundle is a versioned JSON document. Its metadata is always readable;
+// the identifiers themselves can optionally be encrypted with a passphrase
+// using AES-256-GCM and a PBKDF2-SHA256 derived key.
+package bundle
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// Format identifies identity bundle files
+	Format = "cursor-identity-bundle"
+	// Version is the bundle format version written by this package
+	Version = 1
+
+	cipherName    = "aes-256-gcm"
+	kdfName       = "pbkdf2-sha256"
+	kdfIterations = 600000
+	saltSize      = 16
+	keySize       = 32
+
+	// Bounds on the iterations accepted from a bundle: fewer makes brute
+	// force cheap, more lets a crafted file stall the import
+	minKDFIterations = 100_000
+	maxKDFIterations = 10_000_000
+)
+
+var (
+	// ErrPassphraseRequired is returned when opening an encrypted bundle without a passphrase
+	ErrPassphraseRequired = errors.New("bundle is encrypted; a passphrase is required")
+	// ErrDecrypt is returned when the passphrase is wrong or the bundle was modified
+	ErrDecrypt = errors.New("failed to decrypt bundle: wrong passphrase or corrupted file")
+)
+
+// Metadata describes where and when a bundle was created
+type Metadata struct {
+	CreatedAt   time.Time `json:"createdAt"`
+	Hostname    string    `json:"hostname,omitempty"`
+	User        string    `json:"user,omitempty"`
+	OS          string    `json:"os,omitempty"`
+	ToolVersion string    `json:"toolVersion,omitempty"`
+	SourcePath  string    `json:"sourcePath,omitempty"`
+}
+
+// Bundle is a decoded identity bundle
+type Bundle struct {
+	Metadata Metadata
+	Fields   map[string]string // storage.json key to identifier value
+}
+
+// kdfParams records how the encryption key was derived
+type kdfParams struct {
+	Name       string `json:"name"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+}
+
+// envelope is the on-disk representation of a bundle
+type envelope struct {
+	Format     string            `json:"format"`
+	Version    int               `json:"version"`
+	Metadata   Metadata          `json:"metadata"`
+	Encrypted  bool              `json:"encrypted"`
+	Fields     map[string]string `json:"fields,omitempty"`
+	Cipher     string            `json:"cipher,omitempty"`
+	KDF        *kdfParams        `json:"kdf,omitempty"`
+	Nonce      []byte            `json:"nonce,omitempty"`
+	Ciphertext []byte            `json:"ciphertext,omitempty"`
+}
+
+// Marshal encodes a bundle. The fields are encrypted when passphrase is not empty.
+func Marshal(b *Bundle, passphrase []byte) ([]byte, error) {
+	env := envelope{
+		Format:   Format,
+		Version:  Version,
+		Metadata: b.Metadata,
+	}
+
+	if len(passphrase) == 0 {
+		env.Fields = b.Fields
+		return json.MarshalIndent(env, "", "    ")
+	}
+
+	plaintext, err := json.Marshal(b.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal fields: %w", err)
+	}
+
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	env.Encrypted = true
+	env.Cipher = cipherName
+	env.KDF = &kdfParams{Name: kdfName, Iterations: kdfIterations, Salt: salt}
+
+	aead, err := newAEAD(passphrase, env.KDF)
+	if err != nil {
+		return nil, err
+	}
+	env.Nonce = make([]byte, aead.NonceSize())
+	if _, err := rand.Read(env.Nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	aad, err := additionalData(&env)
+	if err != nil {
+		return nil, err
+	}
+	env.Ciphertext = aead.Seal(nil, env.Nonce, plaintext, aad)
+
+	return json.MarshalIndent(env, "", "    ")
+}
+
+// Unmarshal decodes and, if needed, decrypts a bundle
+func Unmarshal(data, passphrase []byte) (*Bundle, error) {
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle: %w", err)
+	}
+	if env.Format != Format {
+		return nil, fmt.Errorf("not an identity bundle (format %q)", env.Format)
+	}
+	if env.Version < 1 || env.Version > Version {
+		return nil, fmt.Errorf("unsupported bundle version %d (supported: 1-%d)", env.Version, Version)
+	}
+
+	b := &Bundle{Metadata: env.Metadata, Fields: env.Fields}
+	if !env.Encrypted {
+		if len(b.Fields) == 0 {
+			return nil, fmt.Errorf("bundle contains no identifiers")
+		}
+		return b, nil
+	}
+
+	if len(passphrase) == 0 {
+		return nil, ErrPassphraseRequired
+	}
+	if env.Cipher != cipherName || env.KDF == nil || env.KDF.Name != kdfName {
+		return nil, fmt.Errorf("unsupported bundle encryption %q", env.Cipher)
+	}
+	if len(env.KDF.Salt) == 0 {
+		return nil, fmt.Errorf("invalid bundle key derivation parameters")
+	}
+	if env.KDF.Iterations < minKDFIterations || env.KDF.Iterations > maxKDFIterations {
+		return nil, fmt.Errorf("unsupported bundle key derivation iterations %d (supported: %d-%d)",
+			env.KDF.Iterations, minKDFIterations, maxKDFIterations)
+	}
+
+	aead, err := newAEAD(passphrase, env.KDF)
+	if err != nil {
+		return nil, err
+	}
+	if len(env.Nonce) != aead.NonceSize() {
+		return nil, fmt.Errorf("invalid bundle nonce")
+	}
+	aad, err := additionalData(&env)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, env.Nonce, env.Ciphertext, aad)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	if err := json.Unmarshal(plaintext, &b.Fields); err != nil {
+		return nil, fmt.Errorf("failed to parse bundle fields: %w", err)
+	}
+	return b, nil
+}
+
+// WriteFile writes a bundle to path, readable only by the owner
+func WriteFile(path string, b *Bundle, passphrase []byte) error {
+	data, err := Marshal(b, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	return nil
+}
+
+// ReadFile reads and decodes the bundle at path
+func ReadFile(path string, passphrase []byte) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bundle: %w", err)
+	}
+	return Unmarshal(data, passphrase)
+}
+
+func newAEAD(passphrase []byte, kdf *kdfParams) (cipher.AEAD, error) {
+	key := pbkdf2(sha256.New, passphrase, kdf.Salt, kdf.Iterations, keySize)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// additionalData binds the readable header to the ciphertext so that
+// metadata and parameters cannot be altered undetected
+func additionalData(env *envelope) ([]byte, error) {
+	header := struct {
+		Format   string     `json:"format"`
+		Version  int        `json:"version"`
+		Metadata Metadata   `json:"metadata"`
+		Cipher   string     `json:"cipher"`
+		KDF      *kdfParams `json:"kdf"`
+	}{env.Format, env.Version, env.Metadata, env.Cipher, env.KDF}
+	return json.Marshal(header)
+}
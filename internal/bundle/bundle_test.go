@@ -0,0 +1,129 @@
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test vectors for PBKDF2-HMAC-SHA256 from RFC 7914, section 11
+func TestPBKDF2(t *testing.T) {
+	tests := []struct {
+		password, salt string
+		iterations     int
+		want           string
+	}{
+		{"passwd", "salt", 1, "55ac046e56e3089fec1691c22544b605f94185216dde0465e68b9d57c20dacbc" +
+			"49ca9cccf179b645991664b39d77ef317c71b845b1e30bd509112041d3a19783"},
+		{"Password", "NaCl", 80000, "4ddcd8f60b98be21830cee5ef22701f9641a4418d04c0414aeff08876b34ab56" +
+			"a1d425a1225833549adb841b51c9b3176a272bdebba1d078478f62b397f33c8d"},
+	}
+	for _, tt := range tests {
+		got := pbkdf2(sha256.New, []byte(tt.password), []byte(tt.salt), tt.iterations, 64)
+		if hex.EncodeToString(got) != tt.want {
+			t.Errorf("pbkdf2(%q, %q, %d) = %x, want %s", tt.password, tt.salt, tt.iterations, got, tt.want)
+		}
+	}
+}
+
+func testBundle() *Bundle {
+	return &Bundle{
+		Metadata: Metadata{CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC), Hostname: "host"},
+		Fields: map[string]string{
+			"telemetry.machineId":   strings.Repeat("ab", 32),
+			"telemetry.devDeviceId": "4f0b5a5e-8a8c-4b4e-9d1f-3c6f2b7a9e01",
+		},
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, passphrase := range []string{"", "correct horse"} {
+		data, err := Marshal(testBundle(), []byte(passphrase))
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		encrypted := passphrase != ""
+		if leaked := strings.Contains(string(data), strings.Repeat("ab", 32)); leaked == encrypted {
+			t.Errorf("encrypted=%v but identifier in output=%v", encrypted, leaked)
+		}
+
+		got, err := Unmarshal(data, []byte(passphrase))
+		if err != nil {
+			t.Fatalf("Unmarshal: %v", err)
+		}
+		want := testBundle()
+		if !got.Metadata.CreatedAt.Equal(want.Metadata.CreatedAt) || got.Metadata.Hostname != want.Metadata.Hostname {
+			t.Errorf("metadata = %+v, want %+v", got.Metadata, want.Metadata)
+		}
+		if len(got.Fields) != len(want.Fields) {
+			t.Fatalf("fields = %v, want %v", got.Fields, want.Fields)
+		}
+		for k, v := range want.Fields {
+			if got.Fields[k] != v {
+				t.Errorf("%s = %q, want %q", k, got.Fields[k], v)
+			}
+		}
+	}
+}
+
+func TestUnmarshalEncryptedErrors(t *testing.T) {
+	data, err := Marshal(testBundle(), []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	if _, err := Unmarshal(data, nil); !errors.Is(err, ErrPassphraseRequired) {
+		t.Errorf("no passphrase: err = %v, want ErrPassphraseRequired", err)
+	}
+	if _, err := Unmarshal(data, []byte("wrong horse")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong passphrase: err = %v, want ErrDecrypt", err)
+	}
+
+	// The header is authenticated, so editing the metadata breaks decryption
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		t.Fatal(err)
+	}
+	env.Metadata.Hostname = "other"
+	tampered, _ := json.Marshal(env)
+	if _, err := Unmarshal(tampered, []byte("correct horse")); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("tampered metadata: err = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestUnmarshalIterationBounds(t *testing.T) {
+	data, err := Marshal(testBundle(), []byte("correct horse"))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	for _, iterations := range []int{0, -1, minKDFIterations - 1, maxKDFIterations + 1, 1 << 40} {
+		var env envelope
+		if err := json.Unmarshal(data, &env); err != nil {
+			t.Fatal(err)
+		}
+		env.KDF.Iterations = iterations
+		edited, _ := json.Marshal(env)
+		_, err := Unmarshal(edited, []byte("correct horse"))
+		if err == nil || !strings.Contains(err.Error(), "iterations") {
+			t.Errorf("iterations %d: err = %v, want it rejected", iterations, err)
+		}
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"not JSON":    "{",
+		"wrong type":  `{"format": "something-else", "version": 1}`,
+		"new version": `{"format": "cursor-identity-bundle", "version": 99}`,
+		"no fields":   `{"format": "cursor-identity-bundle", "version": 1, "encrypted": false}`,
+		"bad cipher":  `{"format": "cursor-identity-bundle", "version": 1, "encrypted": true, "cipher": "rot13"}`,
+	} {
+		if _, err := Unmarshal([]byte(data), []byte("x")); err == nil {
+			t.Errorf("%s: Unmarshal succeeded", name)
+		}
+	}
+}
@@ -1,13 +1,17 @@
 package config
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/yuaotian/go-cursor-help/internal/jsonc"
+	"github.com/yuaotian/go-cursor-help/internal/paths"
 )
 
 // StorageConfig represents the storage configuration
@@ -20,9 +24,52 @@ type StorageConfig struct {
 	Version               string `json:"version"`
 }
 
+// TelemetryKeys lists the identifier keys managed by the tool
+var TelemetryKeys = []string{
+	"telemetry.machineId",
+	"telemetry.macMachineId",
+	"telemetry.devDeviceId",
+	"telemetry.sqmId",
+}
+
+// Get returns the identifier stored under the given storage.json key
+func (c *StorageConfig) Get(key string) (string, bool) {
+	switch key {
+	case "telemetry.machineId":
+		return c.TelemetryMachineId, true
+	case "telemetry.macMachineId":
+		return c.TelemetryMacMachineId, true
+	case "telemetry.devDeviceId":
+		return c.TelemetryDevDeviceId, true
+	case "telemetry.sqmId":
+		return c.TelemetrySqmId, true
+	default:
+		return "", false
+	}
+}
+
+// Set stores an identifier under the given storage.json key
+func (c *StorageConfig) Set(key, value string) bool {
+	switch key {
+	case "telemetry.machineId":
+		c.TelemetryMachineId = value
+	case "telemetry.macMachineId":
+		c.TelemetryMacMachineId = value
+	case "telemetry.devDeviceId":
+		c.TelemetryDevDeviceId = value
+	case "telemetry.sqmId":
+		c.TelemetrySqmId = value
+	default:
+		return false
+	}
+	return true
+}
+
 // Manager handles configuration operations
 type Manager struct {
 	configPath string
+	lockPath   string // Where the modes from before -r are recorded
+	username   string
 	mu         sync.RWMutex
 }
 
@@ -32,7 +79,16 @@ func NewManager(username string) (*Manager, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to get config path: %w", err)
 	}
-	return &Manager{configPath: configPath}, nil
+	lockPath, err := lockStatePath(username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lock state path: %w", err)
+	}
+	return &Manager{configPath: configPath, lockPath: lockPath, username: username}, nil
+}
+
+// ConfigPath returns the location of storage.json
+func (m *Manager) ConfigPath() string {
+	return m.configPath
 }
 
 // ReadConfig reads the existing configuration
@@ -49,7 +105,7 @@ func (m *Manager) ReadConfig() (*StorageConfig, error) {
 	}
 
 	var config StorageConfig
-	if err := json.Unmarshal(data, &config); err != nil {
+	if err := jsonc.Unmarshal(data, &config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
@@ -57,7 +113,45 @@ func (m *Manager) ReadConfig() (*StorageConfig, error) {
 }
 
 // SaveConfig saves the configuration
-func (m *Manager) SaveConfig(config *StorageConfig, readOnly bool) error {
+func (m *Manager) SaveConfig(ctx context.Context, config *StorageConfig, readOnly bool) error {
+	return m.saveFields(ctx, []fieldUpdate{
+		{"telemetry.sqmId", config.TelemetrySqmId},
+		{"telemetry.macMachineId", config.TelemetryMacMachineId},
+		{"telemetry.machineId", config.TelemetryMachineId},
+		{"telemetry.devDeviceId", config.TelemetryDevDeviceId},
+	}, readOnly)
+}
+
+// SetField saves a single identifier, leaving the others untouched
+func (m *Manager) SetField(ctx context.Context, key, value string, readOnly bool) error {
+	return m.SetFields(ctx, map[string]string{key: value}, readOnly)
+}
+
+// SetFields saves the given identifiers, leaving the others untouched
+func (m *Manager) SetFields(ctx context.Context, fields map[string]string, readOnly bool) error {
+	for key := range fields {
+		if _, ok := (&StorageConfig{}).Get(key); !ok {
+			return fmt.Errorf("unknown config field: %s", key)
+		}
+	}
+
+	var updates []fieldUpdate
+	for _, key := range TelemetryKeys {
+		if value, ok := fields[key]; ok {
+			updates = append(updates, fieldUpdate{key, value})
+		}
+	}
+	return m.saveFields(ctx, updates, readOnly)
+}
+
+// fieldUpdate is a single key to write into storage.json
+type fieldUpdate struct {
+	key   string
+	value string
+}
+
+// saveFields writes the given keys and refreshes lastModified
+func (m *Manager) saveFields(ctx context.Context, updates []fieldUpdate, readOnly bool) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
@@ -67,45 +161,193 @@ func (m *Manager) SaveConfig(config *StorageConfig, readOnly bool) error {
 	}
 
 	// Prepare updated configuration
-	updatedConfig := m.prepareUpdatedConfig(config)
+	content, err := m.prepareUpdatedConfig(updates)
+	if err != nil {
+		return err
+	}
 
 	// Write configuration
-	if err := m.writeConfigFile(updatedConfig, readOnly); err != nil {
+	if err := m.writeConfigFile(ctx, content, readOnly); err != nil {
 		return err
 	}
 
 	return nil
 }
 
-// prepareUpdatedConfig merges existing config with updates
-func (m *Manager) prepareUpdatedConfig(config *StorageConfig) map[string]interface{} {
+// prepareUpdatedConfig applies the updates to the existing file, keeping
+// its comments, key order and formatting
+func (m *Manager) prepareUpdatedConfig(updates []fieldUpdate) ([]byte, error) {
 	// Read existing config
-	originalFile := make(map[string]interface{})
-	if data, err := os.ReadFile(m.configPath); err == nil {
-		json.Unmarshal(data, &originalFile)
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read config file: %w", err)
+		}
+		data = []byte("{}")
+	}
+
+	doc, err := jsonc.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
 	}
 
 	// Update fields
-	originalFile["telemetry.sqmId"] = config.TelemetrySqmId
-	originalFile["telemetry.macMachineId"] = config.TelemetryMacMachineId
-	originalFile["telemetry.machineId"] = config.TelemetryMachineId
-	originalFile["telemetry.devDeviceId"] = config.TelemetryDevDeviceId
-	originalFile["lastModified"] = time.Now().UTC().Format(time.RFC3339)
-	// originalFile["version"] = "1.0.1"
+	updates = append(updates, fieldUpdate{"lastModified", time.Now().UTC().Format(time.RFC3339)})
+	// updates = append(updates, fieldUpdate{"version", "1.0.1"})
+	for _, u := range updates {
+		if err := doc.Set(u.value, u.key); err != nil {
+			return nil, fmt.Errorf("failed to update %s: %w", u.key, err)
+		}
+	}
+
+	return doc.Bytes(), nil
+}
 
-	return originalFile
+// BackupDir returns the directory holding storage.json backups
+func (m *Manager) BackupDir() string {
+	return filepath.Join(filepath.Dir(m.configPath), "backups")
 }
 
-// writeConfigFile handles the atomic write of the config file
-func (m *Manager) writeConfigFile(config map[string]interface{}, readOnly bool) error {
-	// Marshal with indentation
-	content, err := json.MarshalIndent(config, "", "    ")
+// Backup copies the current storage.json into the backup directory and
+// returns the path of the copy. It returns an empty path if there is no
+// file to back up.
+func (m *Manager) Backup() (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, err := os.ReadFile(m.configPath)
 	if err != nil {
-		return fmt.Errorf("failed to marshal config: %w", err)
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	if err := os.MkdirAll(m.BackupDir(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	// Backups taken within the same second get a counter so none is overwritten
+	base := filepath.Join(m.BackupDir(), "storage.json.backup_"+time.Now().Format("20060102_150405"))
+	backupPath := base
+	for i := 1; ; i++ {
+		if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+			break
+		}
+		backupPath = fmt.Sprintf("%s_%d", base, i)
+	}
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	paths.ChownToUser(m.BackupDir(), m.username)
+	paths.ChownToUser(backupPath, m.username)
+	return backupPath, nil
+}
+
+// ListBackups returns the paths of existing backups, newest first
+func (m *Manager) ListBackups() ([]string, error) {
+	backups, err := filepath.Glob(filepath.Join(m.BackupDir(), "storage.json.backup_*"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+	// The timestamp suffix sorts lexically
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// PruneBackups deletes all but the newest keep backups and returns the
+// paths that were removed. A keep of zero or less keeps everything.
+func (m *Manager) PruneBackups(keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	backups, err := m.ListBackups()
+	if err != nil || len(backups) <= keep {
+		return nil, err
+	}
+
+	var removed []string
+	for _, backup := range backups[keep:] {
+		if err := os.Remove(backup); err != nil {
+			return removed, fmt.Errorf("failed to remove backup: %w", err)
+		}
+		removed = append(removed, backup)
+	}
+	return removed, nil
+}
+
+// LatestBackup returns the path of the newest backup
+func (m *Manager) LatestBackup() (string, error) {
+	backups, err := m.ListBackups()
+	if err != nil {
+		return "", err
+	}
+	if len(backups) == 0 {
+		return "", fmt.Errorf("no backups found in %s", m.BackupDir())
+	}
+	return backups[0], nil
+}
+
+// RestoreBackup replaces storage.json with the contents of a backup
+func (m *Manager) RestoreBackup(ctx context.Context, backupPath string, readOnly bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	content, err := os.ReadFile(backupPath)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	if _, err := jsonc.Parse(content); err != nil {
+		return fmt.Errorf("backup is not valid JSON: %w", err)
+	}
+	return m.writeConfigFile(ctx, content, readOnly)
+}
+
+// ReadValues reads a storage.json-style file into a generic map, as used
+// when comparing the current file with a backup
+func ReadValues(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	values := make(map[string]interface{})
+	if err := jsonc.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// TempPath returns the temporary file used while writing storage.json
+func (m *Manager) TempPath() string {
+	return m.configPath + ".tmp"
+}
+
+// RemoveTemp removes a temporary file left by an interrupted write. It waits
+// for a write in progress to finish or abort first.
+func (m *Manager) RemoveTemp() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := os.Remove(m.TempPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove temporary file: %w", err)
+	}
+	return nil
+}
+
+// writeConfigFile handles the atomic write of the config file. The file is
+// left untouched when ctx is cancelled before the final rename.
+func (m *Manager) writeConfigFile(ctx context.Context, content []byte, readOnly bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	// Remember the current mode so that unlock can restore it
+	if readOnly {
+		if err := m.recordLock(); err != nil {
+			return fmt.Errorf("failed to record file mode: %w", err)
+		}
 	}
 
 	// Write to temporary file
-	tmpPath := m.configPath + ".tmp"
+	tmpPath := m.TempPath()
 	if err := os.WriteFile(tmpPath, content, 0666); err != nil {
 		return fmt.Errorf("failed to write temporary file: %w", err)
 	}
@@ -121,6 +363,17 @@ func (m *Manager) writeConfigFile(config map[string]interface{}, readOnly bool)
 		return fmt.Errorf("failed to set temporary file permissions: %w", err)
 	}
 
+	// Keep the file owned by the user rather than root when run with sudo
+	if err := paths.ChownToUser(tmpPath, m.username); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to set temporary file owner: %w", err)
+	}
+
+	if err := ctx.Err(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
 	// Atomic rename
 	if err := os.Rename(tmpPath, m.configPath); err != nil {
 		os.Remove(tmpPath)
@@ -133,6 +386,12 @@ func (m *Manager) writeConfigFile(config map[string]interface{}, readOnly bool)
 		dir.Sync()
 	}
 
+	// A writable file no longer needs its lock record; failing to drop it
+	// only leaves a stale entry behind
+	if !readOnly {
+		m.clearLock()
+	}
+
 	return nil
 }
 
@@ -142,10 +401,16 @@ func getConfigPath(username string) (string, error) {
 	switch runtime.GOOS {
 	case "windows":
 		configDir = filepath.Join(os.Getenv("APPDATA"), "Cursor", "User", "globalStorage")
-	case "darwin":
-		configDir = filepath.Join("/Users", username, "Library", "Application Support", "Cursor", "User", "globalStorage")
-	case "linux":
-		configDir = filepath.Join("/home", username, ".config", "Cursor", "User", "globalStorage")
+	case "darwin", "linux":
+		home, err := paths.HomeDir(username)
+		if err != nil {
+			return "", err
+		}
+		if runtime.GOOS == "darwin" {
+			configDir = filepath.Join(home, "Library", "Application Support", "Cursor", "User", "globalStorage")
+		} else {
+			configDir = filepath.Join(home, ".config", "Cursor", "User", "globalStorage")
+		}
 	default:
 		return "", fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
@@ -0,0 +1,169 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/yuaotian/go-cursor-help/internal/paths"
+)
+
+// lockStateFile records, per storage.json, the mode it had before -r made
+// it read-only
+const lockStateFile = "locks.json"
+
+// defaultUnlockMode is used when a read-only file has no lock record
+const defaultUnlockMode os.FileMode = 0644
+
+// LockRecord describes a storage.json that the tool made read-only
+type LockRecord struct {
+	Path         string    `json:"path"`
+	PreviousMode string    `json:"previousMode"` // Octal, e.g. "0644"
+	LockedAt     time.Time `json:"lockedAt"`
+}
+
+// Mode returns the recorded previous mode
+func (r *LockRecord) Mode() (os.FileMode, error) {
+	mode, err := strconv.ParseUint(r.PreviousMode, 8, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid recorded mode %q: %w", r.PreviousMode, err)
+	}
+	return os.FileMode(mode).Perm(), nil
+}
+
+// IsReadOnly reports whether storage.json exists and has no write permission
+func (m *Manager) IsReadOnly() (bool, error) {
+	info, err := os.Stat(m.configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return info.Mode().Perm()&0222 == 0, nil
+}
+
+// LockRecord returns the lock record for storage.json, or nil if the tool
+// has not made it read-only
+func (m *Manager) LockRecord() (*LockRecord, error) {
+	records, err := m.readLockRecords()
+	if err != nil {
+		return nil, err
+	}
+	return records[m.configPath], nil
+}
+
+// Unlock makes storage.json writable again, restoring the mode it had
+// before it was made read-only, and returns the restored mode
+func (m *Manager) Unlock() (os.FileMode, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := os.Stat(m.configPath); err != nil {
+		return 0, fmt.Errorf("failed to stat config file: %w", err)
+	}
+
+	records, err := m.readLockRecords()
+	if err != nil {
+		return 0, err
+	}
+	mode := defaultUnlockMode
+	if record := records[m.configPath]; record != nil {
+		if mode, err = record.Mode(); err != nil {
+			return 0, err
+		}
+	}
+	// Never restore a mode that would leave the file read-only
+	mode |= 0200
+
+	if err := os.Chmod(m.configPath, mode); err != nil {
+		return 0, fmt.Errorf("failed to change file mode: %w", err)
+	}
+	delete(records, m.configPath)
+	return mode, m.writeLockRecords(records)
+}
+
+// recordLock remembers the current mode of storage.json before it is made
+// read-only. An existing record is kept so that locking twice does not
+// lose the original mode.
+func (m *Manager) recordLock() error {
+	records, err := m.readLockRecords()
+	if err != nil {
+		return err
+	}
+	if records[m.configPath] != nil {
+		return nil
+	}
+
+	mode := os.FileMode(0666)
+	if info, err := os.Stat(m.configPath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if mode&0222 == 0 {
+		// Already read-only without a record; nothing meaningful to restore
+		return nil
+	}
+
+	records[m.configPath] = &LockRecord{
+		Path:         m.configPath,
+		PreviousMode: fmt.Sprintf("%04o", uint32(mode)),
+		LockedAt:     time.Now().UTC(),
+	}
+	return m.writeLockRecords(records)
+}
+
+// clearLock forgets the lock record after storage.json was written writable
+func (m *Manager) clearLock() error {
+	records, err := m.readLockRecords()
+	if err != nil {
+		return err
+	}
+	if records[m.configPath] == nil {
+		return nil
+	}
+	delete(records, m.configPath)
+	return m.writeLockRecords(records)
+}
+
+// lockStatePath returns where the lock records of username are kept
+func lockStatePath(username string) (string, error) {
+	dir, err := paths.StateDir(username)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, lockStateFile), nil
+}
+
+func (m *Manager) readLockRecords() (map[string]*LockRecord, error) {
+	records := make(map[string]*LockRecord)
+
+	data, err := os.ReadFile(m.lockPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, nil
+		}
+		return nil, fmt.Errorf("failed to read lock state: %w", err)
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse lock state: %w", err)
+	}
+	return records, nil
+}
+
+func (m *Manager) writeLockRecords(records map[string]*LockRecord) error {
+	if err := paths.EnsureDir(filepath.Dir(m.lockPath), m.username); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(records, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal lock state: %w", err)
+	}
+	if err := os.WriteFile(m.lockPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write lock state: %w", err)
+	}
+	return paths.ChownToUser(m.lockPath, m.username)
+}
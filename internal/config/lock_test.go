@@ -0,0 +1,176 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// setLockRecord stores a lock record for m's storage.json
+func setLockRecord(t *testing.T, m *Manager, previousMode string) {
+	t.Helper()
+	records := map[string]*LockRecord{
+		m.configPath: {Path: m.configPath, PreviousMode: previousMode, LockedAt: time.Now().UTC()},
+	}
+	if err := m.writeLockRecords(records); err != nil {
+		t.Fatalf("writeLockRecords: %v", err)
+	}
+}
+
+func fileMode(t *testing.T, path string) os.FileMode {
+	t.Helper()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info.Mode().Perm()
+}
+
+func TestRecordLock(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     os.FileMode
+		existing string // Previous mode of an existing record, if any
+		want     string // Recorded previous mode, empty for no record
+	}{
+		{"writable", 0644, "", "0644"},
+		{"writable by everyone", 0666, "", "0666"},
+		{"already read-only", 0444, "", ""},
+		{"locked twice", 0444, "0600", "0600"},
+		{"record kept over current mode", 0666, "0640", "0640"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, currentUsername(t), testStorage)
+			if err := os.Chmod(m.configPath, tt.mode); err != nil {
+				t.Fatal(err)
+			}
+			if tt.existing != "" {
+				setLockRecord(t, m, tt.existing)
+			}
+
+			if err := m.recordLock(); err != nil {
+				t.Fatalf("recordLock: %v", err)
+			}
+			record, err := m.LockRecord()
+			if err != nil {
+				t.Fatalf("LockRecord: %v", err)
+			}
+			switch {
+			case tt.want == "" && record != nil:
+				t.Errorf("recorded %s, want no record", record.PreviousMode)
+			case tt.want != "" && record == nil:
+				t.Errorf("no record, want %s", tt.want)
+			case tt.want != "" && record.PreviousMode != tt.want:
+				t.Errorf("recorded %s, want %s", record.PreviousMode, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnlock(t *testing.T) {
+	tests := []struct {
+		name   string
+		record string // Recorded previous mode, empty for no record
+		want   os.FileMode
+	}{
+		{"restores the recorded mode", "0640", 0640},
+		{"restores a mode writable by everyone", "0666", 0666},
+		{"keeps a recorded read-only mode writable", "0400", 0600},
+		{"no record", "", defaultUnlockMode | 0200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := newTestManager(t, currentUsername(t), testStorage)
+			if err := os.Chmod(m.configPath, 0444); err != nil {
+				t.Fatal(err)
+			}
+			if tt.record != "" {
+				setLockRecord(t, m, tt.record)
+			}
+
+			mode, err := m.Unlock()
+			if err != nil {
+				t.Fatalf("Unlock: %v", err)
+			}
+			if mode != tt.want {
+				t.Errorf("Unlock = %v, want %v", mode, tt.want)
+			}
+			if got := fileMode(t, m.configPath); got != tt.want {
+				t.Errorf("file mode = %v, want %v", got, tt.want)
+			}
+			if record, err := m.LockRecord(); err != nil || record != nil {
+				t.Errorf("LockRecord = %+v, %v; want the record removed", record, err)
+			}
+		})
+	}
+}
+
+func TestUnlockInvalidRecord(t *testing.T) {
+	m := newTestManager(t, currentUsername(t), testStorage)
+	if err := os.Chmod(m.configPath, 0444); err != nil {
+		t.Fatal(err)
+	}
+	setLockRecord(t, m, "rw-r--r--")
+
+	if _, err := m.Unlock(); err == nil {
+		t.Error("Unlock succeeded with an invalid record")
+	}
+	if got := fileMode(t, m.configPath); got != 0444 {
+		t.Errorf("file mode = %v, want it unchanged", got)
+	}
+}
+
+func TestUnlockKeepsOtherRecords(t *testing.T) {
+	m := newTestManager(t, currentUsername(t), testStorage)
+	other := &LockRecord{Path: "/other/storage.json", PreviousMode: "0600"}
+	records := map[string]*LockRecord{
+		m.configPath: {Path: m.configPath, PreviousMode: "0644"},
+		other.Path:   other,
+	}
+	if err := m.writeLockRecords(records); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Unlock(); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	records, err := m.readLockRecords()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[other.Path] == nil {
+		t.Errorf("records = %v, want only %s", records, other.Path)
+	}
+}
+
+func TestLockRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, currentUsername(t), testStorage)
+	if err := os.Chmod(m.configPath, 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writing read-only twice keeps the mode from before the first write
+	for i := 0; i < 2; i++ {
+		if err := m.SaveConfig(ctx, testConfig, true); err != nil {
+			t.Fatalf("SaveConfig: %v", err)
+		}
+	}
+	if readOnly, err := m.IsReadOnly(); err != nil || !readOnly {
+		t.Fatalf("IsReadOnly = %v, %v; want true", readOnly, err)
+	}
+	if mode, err := m.Unlock(); err != nil || mode != 0640 {
+		t.Errorf("Unlock = %v, %v; want %v", mode, err, os.FileMode(0640))
+	}
+
+	// A writable write drops a record left behind
+	setLockRecord(t, m, "0600")
+	if err := m.SaveConfig(ctx, testConfig, false); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+	if record, err := m.LockRecord(); err != nil || record != nil {
+		t.Errorf("LockRecord = %+v, %v; want the record removed", record, err)
+	}
+}
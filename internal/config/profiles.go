@@ -0,0 +1,54 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"github.com/yuaotian/go-cursor-help/internal/paths"
+)
+
+// Profile is a location where Cursor may keep storage.json
+type Profile struct {
+	Name   string `json:"name"`
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+	Active bool   `json:"active"` // The location NewManager uses
+}
+
+// Profiles returns the storage.json locations known for username, the
+// active one first. Other locations are used by non-standard installs,
+// such as the snap package or a custom XDG_CONFIG_HOME.
+func Profiles(username string) ([]Profile, error) {
+	active, err := getConfigPath(username)
+	if err != nil {
+		return nil, err
+	}
+	profiles := []Profile{{Name: "default", Path: active, Active: true}}
+
+	add := func(name, cursorDir string) {
+		path := filepath.Join(cursorDir, "User", "globalStorage", "storage.json")
+		if path != active {
+			profiles = append(profiles, Profile{Name: name, Path: path})
+		}
+	}
+	switch runtime.GOOS {
+	case "linux":
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			add("XDG_CONFIG_HOME", filepath.Join(xdg, "Cursor"))
+		}
+		if home, err := paths.HomeDir(username); err == nil {
+			add("snap", filepath.Join(home, "snap", "cursor", "current", ".config", "Cursor"))
+		}
+	case "windows":
+		if home, err := paths.HomeDir(username); err == nil {
+			add("user profile", filepath.Join(home, "AppData", "Roaming", "Cursor"))
+		}
+	}
+
+	for i := range profiles {
+		_, err := os.Stat(profiles[i].Path)
+		profiles[i].Exists = err == nil
+	}
+	return profiles, nil
+}
@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/yuaotian/go-cursor-help/internal/paths"
+)
+
+// VerifyError lists what Verify found wrong with storage.json
+type VerifyError struct {
+	Problems []string
+}
+
+func (e *VerifyError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// Verify reads storage.json back after a write and checks that it is valid
+// JSON holding the identifiers of want, that every other key except
+// lastModified is unchanged from backupPath, if given, and that the file
+// has the owner and mode the write gives it. It returns a *VerifyError
+// describing any mismatch.
+func (m *Manager) Verify(want *StorageConfig, backupPath string, readOnly bool) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	values, err := ReadValues(m.configPath)
+	if err != nil {
+		return &VerifyError{Problems: []string{err.Error()}}
+	}
+
+	var problems []string
+	for _, key := range TelemetryKeys {
+		expected, _ := want.Get(key)
+		if actual, _ := values[key].(string); actual != expected {
+			problems = append(problems, fmt.Sprintf("%s does not have the new value", key))
+		}
+	}
+
+	if backupPath != "" {
+		before, err := ReadValues(backupPath)
+		if err != nil {
+			return fmt.Errorf("failed to read backup for comparison: %w", err)
+		}
+		problems = append(problems, compareOtherKeys(before, values)...)
+	}
+
+	info, err := os.Stat(m.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat config file: %w", err)
+	}
+	wantMode := os.FileMode(0666)
+	if readOnly {
+		wantMode = 0444
+	}
+	if mode := info.Mode().Perm(); mode != wantMode {
+		problems = append(problems, fmt.Sprintf("mode is %v instead of %v", mode, wantMode))
+	}
+
+	// The write only hands the file to the user when running as root
+	if owner, ok := paths.FileOwner(m.configPath); ok && os.Geteuid() == 0 {
+		if u, err := user.Lookup(m.username); err == nil && owner != u.Uid {
+			problems = append(problems, fmt.Sprintf("owned by uid %s instead of %s (uid %s)", owner, m.username, u.Uid))
+		}
+	}
+
+	if len(problems) > 0 {
+		return &VerifyError{Problems: problems}
+	}
+	return nil
+}
+
+// compareOtherKeys reports the keys other than the identifiers and
+// lastModified that differ between before and after
+func compareOtherKeys(before, after map[string]interface{}) []string {
+	skip := map[string]bool{"lastModified": true}
+	for _, key := range TelemetryKeys {
+		skip[key] = true
+	}
+
+	var problems []string
+	for key, old := range before {
+		if skip[key] {
+			continue
+		}
+		if value, ok := after[key]; !ok {
+			problems = append(problems, fmt.Sprintf("%s was removed", key))
+		} else if !reflect.DeepEqual(old, value) {
+			problems = append(problems, fmt.Sprintf("%s was changed", key))
+		}
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok && !skip[key] {
+			problems = append(problems, fmt.Sprintf("%s was added", key))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
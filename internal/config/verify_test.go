@@ -0,0 +1,237 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const testStorage = `{
+	"telemetry.machineId": "old-machine",
+	"telemetry.macMachineId": "old-mac",
+	"telemetry.devDeviceId": "old-device",
+	"telemetry.sqmId": "{OLD-SQM}",
+	"window.zoomLevel": 1,
+	"lastModified": "2024-05-01T12:00:00Z"
+}
+`
+
+// oldConfig holds the identifiers of testStorage, testConfig new ones
+var oldConfig = &StorageConfig{
+	TelemetryMachineId:    "old-machine",
+	TelemetryMacMachineId: "old-mac",
+	TelemetryDevDeviceId:  "old-device",
+	TelemetrySqmId:        "{OLD-SQM}",
+}
+
+var testConfig = &StorageConfig{
+	TelemetryMachineId:    "new-machine",
+	TelemetryMacMachineId: "new-mac",
+	TelemetryDevDeviceId:  "new-device",
+	TelemetrySqmId:        "{NEW-SQM}",
+}
+
+// newTestManager returns a manager for a storage.json in a temporary
+// directory holding content, writable by everyone as a write leaves it.
+// Lock records are kept in the same directory.
+func newTestManager(t *testing.T, username, content string) *Manager {
+	t.Helper()
+	dir := t.TempDir()
+	m := &Manager{
+		configPath: filepath.Join(dir, "storage.json"),
+		lockPath:   filepath.Join(dir, "state", lockStateFile),
+		username:   username,
+	}
+	if err := os.WriteFile(m.configPath, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(m.configPath, 0666); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func currentUsername(t *testing.T) string {
+	t.Helper()
+	u, err := user.Current()
+	if err != nil {
+		t.Fatalf("user.Current: %v", err)
+	}
+	return u.Username
+}
+
+// verifyProblems runs Verify and returns the problems it found
+func verifyProblems(t *testing.T, m *Manager, want *StorageConfig, backupPath string, readOnly bool) []string {
+	t.Helper()
+	err := m.Verify(want, backupPath, readOnly)
+	if err == nil {
+		return nil
+	}
+	var verifyErr *VerifyError
+	if !errors.As(err, &verifyErr) {
+		t.Fatalf("Verify: %v, want a *VerifyError", err)
+	}
+	return verifyErr.Problems
+}
+
+func TestVerify(t *testing.T) {
+	m := newTestManager(t, currentUsername(t), testStorage)
+	backupPath, err := m.Backup()
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if err := m.SaveConfig(context.Background(), testConfig, false); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	if problems := verifyProblems(t, m, testConfig, backupPath, false); problems != nil {
+		t.Errorf("Verify found %v after a clean write", problems)
+	}
+}
+
+func TestVerifyValueMismatch(t *testing.T) {
+	m := newTestManager(t, currentUsername(t), `{
+	"telemetry.machineId": "new-machine",
+	"telemetry.macMachineId": "old-mac",
+	"telemetry.devDeviceId": "new-device",
+	"window.zoomLevel": 2,
+	"editor.fontSize": 14
+}`)
+	backupPath := filepath.Join(t.TempDir(), "backup.json")
+	if err := os.WriteFile(backupPath, []byte(testStorage), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"telemetry.macMachineId does not have the new value",
+		"telemetry.sqmId does not have the new value",
+		"editor.fontSize was added",
+		"window.zoomLevel was changed",
+	}
+	if got := verifyProblems(t, m, testConfig, backupPath, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %q\nwant %q", got, want)
+	}
+}
+
+func TestVerifyRemovedKey(t *testing.T) {
+	m := newTestManager(t, currentUsername(t), `{
+	"telemetry.machineId": "new-machine",
+	"telemetry.macMachineId": "new-mac",
+	"telemetry.devDeviceId": "new-device",
+	"telemetry.sqmId": "{NEW-SQM}"
+}`)
+	backupPath := filepath.Join(t.TempDir(), "backup.json")
+	if err := os.WriteFile(backupPath, []byte(testStorage), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// lastModified is expected to change and is not reported
+	want := []string{"window.zoomLevel was removed"}
+	if got := verifyProblems(t, m, testConfig, backupPath, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %q, want %q", got, want)
+	}
+}
+
+func TestVerifyInvalidJSON(t *testing.T) {
+	m := newTestManager(t, currentUsername(t), `{"telemetry.machineId": `)
+	if problems := verifyProblems(t, m, testConfig, "", false); len(problems) != 1 {
+		t.Errorf("problems = %q, want the parse error", problems)
+	}
+}
+
+func TestVerifyMissingBackup(t *testing.T) {
+	m := newTestManager(t, currentUsername(t), testStorage)
+	err := m.Verify(testConfig, filepath.Join(t.TempDir(), "missing"), false)
+	var verifyErr *VerifyError
+	if err == nil || errors.As(err, &verifyErr) {
+		t.Errorf("Verify = %v, want a plain error for the unreadable backup", err)
+	}
+}
+
+func TestVerifyMode(t *testing.T) {
+	m := newTestManager(t, currentUsername(t), testStorage)
+
+	tests := []struct {
+		mode     os.FileMode
+		readOnly bool
+		want     []string
+	}{
+		{0666, false, nil},
+		{0444, true, nil},
+		{0644, false, []string{"mode is -rw-r--r-- instead of -rw-rw-rw-"}},
+		{0666, true, []string{"mode is -rw-rw-rw- instead of -r--r--r--"}},
+	}
+	for _, tt := range tests {
+		if err := os.Chmod(m.configPath, tt.mode); err != nil {
+			t.Fatal(err)
+		}
+		if got := verifyProblems(t, m, oldConfig, "", tt.readOnly); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("mode %v, read-only %v: problems = %q, want %q", tt.mode, tt.readOnly, got, tt.want)
+		}
+	}
+}
+
+func TestVerifyOwner(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("the owner is only checked when running as root")
+	}
+	other, err := user.Lookup("nobody")
+	if err != nil {
+		t.Skip("no user nobody to hand the file to")
+	}
+
+	// Written as root for nobody but never handed over
+	m := newTestManager(t, other.Username, testStorage)
+	want := []string{"owned by uid 0 instead of nobody (uid " + other.Uid + ")"}
+	if got := verifyProblems(t, m, oldConfig, "", false); !reflect.DeepEqual(got, want) {
+		t.Errorf("problems = %q, want %q", got, want)
+	}
+}
+
+func TestRestoreAfterMismatch(t *testing.T) {
+	ctx := context.Background()
+	m := newTestManager(t, currentUsername(t), testStorage)
+	backupPath, err := m.Backup()
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if err := m.SaveConfig(ctx, testConfig, false); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+
+	// Another writer replaces one of the new identifiers before the check
+	if err := m.SaveConfig(ctx, &StorageConfig{
+		TelemetryMachineId:    "new-machine",
+		TelemetryMacMachineId: "new-mac",
+		TelemetryDevDeviceId:  "new-device",
+		TelemetrySqmId:        "{OTHER}",
+	}, false); err != nil {
+		t.Fatalf("SaveConfig: %v", err)
+	}
+	if problems := verifyProblems(t, m, testConfig, backupPath, false); len(problems) == 0 {
+		t.Fatal("Verify found no problems in a mismatched file")
+	}
+
+	// Restoring the backup puts back the file exactly as it was
+	if err := m.RestoreBackup(ctx, backupPath, false); err != nil {
+		t.Fatalf("RestoreBackup: %v", err)
+	}
+	data, err := os.ReadFile(m.configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != testStorage {
+		t.Errorf("restored storage.json =\n%s\nwant\n%s", data, testStorage)
+	}
+	restored, err := m.ReadConfig()
+	if err != nil {
+		t.Fatalf("ReadConfig: %v", err)
+	}
+	if problems := verifyProblems(t, m, restored, backupPath, false); problems != nil {
+		t.Errorf("Verify found %v after restoring the backup", problems)
+	}
+}
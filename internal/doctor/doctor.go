@@ -0,0 +1,276 @@
+// Package doctor diagnoses common environment problems that prevent the
+// tool from updating Cursor's identifiers
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/yuaotian/go-cursor-help/internal/config"
+	"github.com/yuaotian/go-cursor-help/internal/jsonc"
+	"github.com/yuaotian/go-cursor-help/internal/lang"
+	"github.com/yuaotian/go-cursor-help/internal/paths"
+	"github.com/yuaotian/go-cursor-help/internal/process"
+	"github.com/yuaotian/go-cursor-help/pkg/idgen"
+)
+
+// Status is the outcome of a single check
+type Status string
+
+const (
+	Pass Status = "pass"
+	Warn Status = "warn"
+	Fail Status = "fail"
+)
+
+// Result is the outcome of a check with a suggested fix when it did not pass
+type Result struct {
+	Name    string `json:"name"`
+	Status  Status `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+// Checker runs the diagnostic checks
+type Checker struct {
+	Username       string
+	ConfigManager  *config.Manager
+	ProcessManager *process.Manager
+	Generator      *idgen.Generator
+	IsAdmin        func() (bool, error)
+	FieldIDTypes   map[string]string // storage.json key to idgen ID type
+	ProgramName    string            // Name the tool was run as, used in fixes
+}
+
+// Run executes every check in order
+func (c *Checker) Run(ctx context.Context) []Result {
+	results := []Result{c.checkConfigPath()}
+
+	// The file checks only make sense when storage.json exists
+	if _, err := os.Stat(c.ConfigManager.ConfigPath()); err == nil {
+		results = append(results, c.checkOwnership(), c.checkMode())
+		jsonResult, doc := c.checkJSON()
+		results = append(results, jsonResult)
+		if doc != nil {
+			results = append(results, c.checkIdentifiers(doc)...)
+		}
+	}
+
+	return append(results, c.checkProcesses(ctx), c.checkPrivileges(), c.checkLocale(), c.checkTranslations())
+}
+
+// Failed reports whether any result failed
+func Failed(results []Result) bool {
+	for _, r := range results {
+		if r.Status == Fail {
+			return true
+		}
+	}
+	return false
+}
+
+// programName returns the name to suggest commands with
+func (c *Checker) programName() string {
+	if c.ProgramName != "" {
+		return c.ProgramName
+	}
+	return paths.AppName
+}
+
+func (c *Checker) checkConfigPath() Result {
+	name := lang.T("DoctorConfigPath")
+	configPath := c.ConfigManager.ConfigPath()
+
+	if _, err := os.Stat(configPath); err == nil {
+		return Result{Name: name, Status: Pass, Message: configPath}
+	} else if !os.IsNotExist(err) {
+		return Result{Name: name, Status: Fail, Message: lang.T("DoctorConfigInaccessible", lang.Args{"path": configPath, "error": err}),
+			Fix: lang.T("DoctorConfigInaccessibleFix")}
+	}
+
+	for _, candidate := range c.alternativeConfigPaths() {
+		if _, err := os.Stat(candidate); err == nil {
+			return Result{Name: name, Status: Fail,
+				Message: lang.T("DoctorConfigElsewhere", lang.Args{"path": configPath, "found": candidate}),
+				Fix:     lang.T("DoctorConfigElsewhereFix")}
+		}
+	}
+
+	if _, err := os.Stat(filepath.Dir(configPath)); err == nil {
+		return Result{Name: name, Status: Warn, Message: lang.T("DoctorConfigNotCreated", lang.Args{"path": configPath}),
+			Fix: lang.T("DoctorConfigNotCreatedFix")}
+	}
+	return Result{Name: name, Status: Fail, Message: lang.T("DoctorConfigNotFound", lang.Args{"path": configPath}),
+		Fix: lang.T("DoctorConfigNotFoundFix", lang.Args{"user": c.Username})}
+}
+
+// alternativeConfigPaths lists other places Cursor keeps storage.json
+func (c *Checker) alternativeConfigPaths() []string {
+	profiles, err := config.Profiles(c.Username)
+	if err != nil {
+		return nil
+	}
+	var candidates []string
+	for _, p := range profiles {
+		if p.Path != c.ConfigManager.ConfigPath() {
+			candidates = append(candidates, p.Path)
+		}
+	}
+	return candidates
+}
+
+func (c *Checker) checkOwnership() Result {
+	name := lang.T("DoctorOwnership")
+	configPath := c.ConfigManager.ConfigPath()
+
+	owner, ok := paths.FileOwner(configPath)
+	if !ok {
+		return Result{Name: name, Status: Pass, Message: lang.T("DoctorNotChecked")}
+	}
+	u, err := user.Lookup(c.Username)
+	if err != nil {
+		return Result{Name: name, Status: Warn, Message: lang.T("DoctorUserLookupFailed", lang.Args{"user": c.Username, "error": err})}
+	}
+	if owner != u.Uid {
+		return Result{Name: name, Status: Fail,
+			Message: lang.T("DoctorWrongOwner", lang.Args{"owner": owner, "user": c.Username, "uid": u.Uid}),
+			Fix:     fmt.Sprintf("sudo chown %s %q", c.Username, configPath)}
+	}
+	return Result{Name: name, Status: Pass, Message: lang.T("DoctorOwnedBy", lang.Args{"user": c.Username})}
+}
+
+func (c *Checker) checkMode() Result {
+	name := lang.T("DoctorMode")
+	configPath := c.ConfigManager.ConfigPath()
+
+	info, err := os.Stat(configPath)
+	if err != nil {
+		return Result{Name: name, Status: Fail, Message: err.Error()}
+	}
+	mode := info.Mode().Perm()
+	if mode&0200 == 0 {
+		message := lang.T("DoctorReadOnly", lang.Args{"mode": mode})
+		if record, err := c.ConfigManager.LockRecord(); err == nil && record != nil {
+			message = lang.T("DoctorLockedByReset", lang.Args{
+				"date": record.LockedAt.Local().Format("2006-01-02 15:04"),
+				"mode": record.PreviousMode,
+			})
+		}
+		return Result{Name: name, Status: Warn,
+			Message: message,
+			Fix:     c.programName() + " unlock"}
+	}
+	return Result{Name: name, Status: Pass, Message: mode.String()}
+}
+
+func (c *Checker) checkJSON() (Result, *jsonc.Document) {
+	name := lang.T("DoctorJSON")
+	data, err := os.ReadFile(c.ConfigManager.ConfigPath())
+	if err != nil {
+		return Result{Name: name, Status: Fail, Message: err.Error(),
+			Fix: lang.T("DoctorReadFix")}, nil
+	}
+	doc, err := jsonc.Parse(data)
+	if err != nil {
+		return Result{Name: name, Status: Fail, Message: err.Error(),
+			Fix: lang.T("DoctorRestoreOrDeleteFix")}, nil
+	}
+	if doc.Root().Kind != jsonc.Object {
+		return Result{Name: name, Status: Fail, Message: lang.T("DoctorNotObject", lang.Args{"kind": doc.Root().Kind}),
+			Fix: lang.T("DoctorRestoreFix")}, nil
+	}
+	return Result{Name: name, Status: Pass, Message: lang.T("DoctorValid")}, doc
+}
+
+func (c *Checker) checkIdentifiers(doc *jsonc.Document) []Result {
+	var results []Result
+	for _, key := range config.TelemetryKeys {
+		name := lang.T("DoctorIdentifier", lang.Args{"field": key})
+
+		var value string
+		if err := doc.Get(&value, key); err != nil {
+			results = append(results, Result{Name: name, Status: Warn, Message: lang.T("DoctorIdentifierMissing"),
+				Fix: lang.T("DoctorResetFix")})
+			continue
+		}
+		if !c.Generator.ValidateID(value, c.FieldIDTypes[key]) {
+			results = append(results, Result{Name: name, Status: Fail, Message: lang.T("DoctorIdentifierInvalid"),
+				Fix: lang.T("DoctorResetFix")})
+			continue
+		}
+		results = append(results, Result{Name: name, Status: Pass, Message: lang.T("DoctorValid")})
+	}
+	return results
+}
+
+func (c *Checker) checkProcesses(ctx context.Context) Result {
+	name := lang.T("DoctorProcesses")
+	pids, err := c.ProcessManager.CursorProcesses(ctx)
+	if err != nil {
+		return Result{Name: name, Status: Warn, Message: lang.T("DoctorProcessListFailed", lang.Args{"error": err})}
+	}
+	if len(pids) > 0 {
+		return Result{Name: name, Status: Warn,
+			Message: lang.T("DoctorCursorRunning", lang.Args{lang.CountArg: len(pids), "pids": strings.Join(pids, ", ")}),
+			Fix:     lang.T("DoctorCloseCursorFix")}
+	}
+	return Result{Name: name, Status: Pass, Message: lang.T("DoctorCursorNotRunning")}
+}
+
+func (c *Checker) checkPrivileges() Result {
+	name := lang.T("DoctorPrivileges")
+	isAdmin, err := c.IsAdmin()
+	if err != nil {
+		return Result{Name: name, Status: Fail, Message: err.Error()}
+	}
+	if !isAdmin {
+		fix := lang.T("DoctorSudoFix")
+		if runtime.GOOS == "windows" {
+			fix = lang.T("DoctorAdministratorFix")
+		}
+		return Result{Name: name, Status: Warn, Message: lang.T("DoctorNotAdmin"), Fix: fix}
+	}
+	return Result{Name: name, Status: Pass, Message: lang.T("DoctorAdmin")}
+}
+
+func (c *Checker) checkLocale() Result {
+	name := lang.T("DoctorLocale")
+	var settings []string
+	for _, envVar := range []string{"LC_ALL", "LC_MESSAGES", "LANG", "LANGUAGE"} {
+		if value := os.Getenv(envVar); value != "" {
+			settings = append(settings, envVar+"="+value)
+		}
+	}
+
+	args := lang.Args{"language": lang.GetCurrentLanguage()}
+	if len(settings) == 0 {
+		if runtime.GOOS != "windows" {
+			return Result{Name: name, Status: Warn, Message: lang.T("DoctorNoLocale", args),
+				Fix: lang.T("DoctorNoLocaleFix")}
+		}
+		return Result{Name: name, Status: Pass, Message: lang.T("DoctorLanguage", args)}
+	}
+	args["settings"] = strings.Join(settings, ", ")
+	return Result{Name: name, Status: Pass, Message: lang.T("DoctorLanguageFrom", args)}
+}
+
+func (c *Checker) checkTranslations() Result {
+	name := lang.T("DoctorTranslations")
+	current := lang.GetCurrentLanguage()
+	missing := lang.Missing(current)
+	if len(missing) > 0 {
+		return Result{Name: name, Status: Warn,
+			Message: lang.T("DoctorUntranslated", lang.Args{
+				lang.CountArg: len(missing),
+				"language":    current,
+				"keys":        strings.Join(missing, ", "),
+			}),
+			Fix: lang.T("DoctorUntranslatedFix")}
+	}
+	return Result{Name: name, Status: Pass, Message: lang.T("DoctorTranslated", lang.Args{"language": current})}
+}
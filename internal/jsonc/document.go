@@ -0,0 +1,389 @@
+package jsonc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultIndent is used when the document gives no hint about its indentation
+const defaultIndent = "    "
+
+// Document is a parsed JSONC text that can be queried and edited in place
+type Document struct {
+	src  []byte
+	root *Node
+}
+
+// Parse parses JSONC source into an editable document
+func Parse(data []byte) (*Document, error) {
+	src := append([]byte(nil), data...)
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{src: src, root: root}, nil
+}
+
+// Unmarshal parses JSONC data and stores the result in the value pointed to by v
+func Unmarshal(data []byte, v interface{}) error {
+	doc, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	return doc.Decode(v)
+}
+
+// Standardize converts JSONC to plain JSON by blanking out comments and
+// trailing commas. Byte offsets are preserved.
+func Standardize(data []byte) ([]byte, error) {
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return doc.standardize(), nil
+}
+
+// Bytes returns the current source of the document
+func (d *Document) Bytes() []byte {
+	return append([]byte(nil), d.src...)
+}
+
+// Root returns the top-level value of the document
+func (d *Document) Root() *Node {
+	return d.root
+}
+
+// Decode decodes the whole document into v using encoding/json semantics
+func (d *Document) Decode(v interface{}) error {
+	return json.Unmarshal(d.standardize(), v)
+}
+
+// Lookup returns the value at the given object key path, or nil if absent
+func (d *Document) Lookup(path ...string) *Node {
+	node := d.root
+	for _, key := range path {
+		member := findMember(node, key)
+		if member == nil {
+			return nil
+		}
+		node = member.Value
+	}
+	return node
+}
+
+// Get decodes the value at the given key path into v.
+// It returns an error if the path does not exist.
+func (d *Document) Get(v interface{}, path ...string) error {
+	node := d.Lookup(path...)
+	if node == nil {
+		return fmt.Errorf("jsonc: key %q not found", strings.Join(path, "/"))
+	}
+	std := d.standardize()
+	return json.Unmarshal(std[node.Offset:node.End], v)
+}
+
+// Set stores value at the given key path, replacing an existing value in
+// place or appending a new member to the innermost existing object.
+// Missing intermediate objects are created.
+func (d *Document) Set(value interface{}, path ...string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("jsonc: empty key path")
+	}
+
+	parent := d.root
+	for i, key := range path {
+		if parent.Kind != Object {
+			return fmt.Errorf("jsonc: %q is not an object", strings.Join(path[:i], "/"))
+		}
+		member := findMember(parent, key)
+		if member == nil {
+			// Build the remaining path as nested objects around value
+			for j := len(path) - 1; j > i; j-- {
+				value = map[string]interface{}{path[j]: value}
+			}
+			return d.insertMember(parent, key, value)
+		}
+		if i == len(path)-1 {
+			text, err := d.marshal(value, d.lineIndent(member.KeyOffset))
+			if err != nil {
+				return err
+			}
+			return d.apply(edit{member.Value.Offset, member.Value.End, text})
+		}
+		parent = member.Value
+	}
+	return nil
+}
+
+// Delete removes the member at the given key path. Deleting a missing key
+// is not an error.
+func (d *Document) Delete(path ...string) error {
+	if len(path) == 0 {
+		return fmt.Errorf("jsonc: empty key path")
+	}
+	parent := d.Lookup(path[:len(path)-1]...)
+	if parent == nil || parent.Kind != Object {
+		return nil
+	}
+
+	key := path[len(path)-1]
+	for i, member := range parent.Members {
+		if member.Key != key {
+			continue
+		}
+
+		end := member.Value.End
+		if member.Comma >= 0 {
+			end = member.Comma + 1
+		}
+		start := member.KeyOffset
+
+		var edits []edit
+		if d.onOwnLine(start) && d.restOfLineBlank(end) {
+			start = d.lineStart(start)
+			end = d.nextLine(end)
+		} else if member.Comma >= 0 {
+			// Drop the space after the comma so the next token takes its place
+			for end < len(d.src) && (d.src[end] == ' ' || d.src[end] == '\t') {
+				end++
+			}
+		}
+		if member.Comma < 0 && i > 0 {
+			// Drop the separator that preceded the removed last member
+			prev := parent.Members[i-1]
+			if prev.Comma >= 0 {
+				edits = append(edits, edit{prev.Comma, prev.Comma + 1, ""})
+			}
+		}
+		edits = append(edits, edit{start, end, ""})
+		return d.apply(edits...)
+	}
+	return nil
+}
+
+// Internal methods
+
+type edit struct {
+	start, end int
+	text       string
+}
+
+// apply replaces source ranges and re-parses the result. The document is
+// left untouched if the edited source does not parse.
+func (d *Document) apply(edits ...edit) error {
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+
+	src := append([]byte(nil), d.src...)
+	for _, e := range edits {
+		var buf bytes.Buffer
+		buf.Write(src[:e.start])
+		buf.WriteString(e.text)
+		buf.Write(src[e.end:])
+		src = buf.Bytes()
+	}
+
+	root, err := parse(src)
+	if err != nil {
+		return fmt.Errorf("jsonc: edit produced invalid document: %w", err)
+	}
+	d.src, d.root = src, root
+	return nil
+}
+
+// insertMember appends key/value to obj, following the formatting of the
+// surrounding members
+func (d *Document) insertMember(obj *Node, key string, value interface{}) error {
+	closeOff := obj.End - 1
+	unit := d.indentUnit()
+
+	memberIndent := d.lineIndent(closeOff) + unit
+	if len(obj.Members) > 0 && d.onOwnLine(obj.Members[0].KeyOffset) {
+		memberIndent = d.lineIndent(obj.Members[0].KeyOffset)
+	}
+
+	keyText, err := d.marshal(key, "")
+	if err != nil {
+		return err
+	}
+	valueText, err := d.marshal(value, memberIndent)
+	if err != nil {
+		return err
+	}
+	member := keyText + ": " + valueText
+
+	var last *Member
+	if len(obj.Members) > 0 {
+		last = obj.Members[len(obj.Members)-1]
+	}
+
+	switch {
+	case d.onOwnLine(closeOff) && (last == nil || d.onOwnLine(last.KeyOffset)):
+		var edits []edit
+		text := memberIndent + member
+		if last != nil && last.Comma >= 0 {
+			text += ","
+		} else if last != nil {
+			edits = append(edits, edit{last.Value.End, last.Value.End, ","})
+		}
+		at := d.lineStart(closeOff)
+		edits = append(edits, edit{at, at, text + "\n"})
+		return d.apply(edits...)
+
+	case last != nil && d.onOwnLine(last.KeyOffset):
+		// The closing brace follows the last member, perhaps after a
+		// comment; start a new line after that comment
+		at := closeOff
+		for at > last.Value.End && (d.src[at-1] == ' ' || d.src[at-1] == '\t') {
+			at--
+		}
+		text := "\n" + memberIndent + member
+		var edits []edit
+		if last.Comma < 0 && at == last.Value.End {
+			text = "," + text
+		} else if last.Comma < 0 {
+			edits = append(edits, edit{last.Value.End, last.Value.End, ","})
+		}
+		edits = append(edits, edit{at, closeOff, text})
+		return d.apply(edits...)
+
+	case last == nil && len(bytes.TrimSpace(d.src[obj.Offset+1:closeOff])) == 0:
+		indent := d.lineIndent(obj.Offset)
+		return d.apply(edit{obj.Offset + 1, closeOff, "\n" + memberIndent + member + "\n" + indent})
+
+	case last == nil:
+		return d.apply(edit{closeOff, closeOff, member})
+
+	case last.Comma >= 0:
+		return d.apply(edit{last.Comma + 1, last.Comma + 1, " " + member + ","})
+
+	default:
+		return d.apply(edit{last.Value.End, last.Value.End, ", " + member})
+	}
+}
+
+// marshal encodes value as JSON, indenting nested lines with prefix
+func (d *Document) marshal(value interface{}, prefix string) (string, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent(prefix, d.indentUnit())
+	if err := enc.Encode(value); err != nil {
+		return "", fmt.Errorf("jsonc: failed to marshal value: %w", err)
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+// standardize returns the source with comments and trailing commas blanked
+func (d *Document) standardize() []byte {
+	out := append([]byte(nil), d.src...)
+	blank := func(start, end int) {
+		for i := start; i < end; i++ {
+			if out[i] != '\n' && out[i] != '\r' {
+				out[i] = ' '
+			}
+		}
+	}
+
+	// Blank comments and the byte order mark, skipping over strings
+	for i := 0; i < len(out); i++ {
+		switch {
+		case i == 0 && bytes.HasPrefix(out, []byte{0xEF, 0xBB, 0xBF}):
+			blank(0, 3)
+			i = 2
+		case out[i] == '"':
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			end := bytes.IndexByte(out[i:], '\n')
+			if end < 0 {
+				end = len(out) - i
+			}
+			blank(i, i+end)
+			i += end
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			blank(i, i+2+end+2)
+			i += 2 + end + 1
+		}
+	}
+
+	var walk func(n *Node)
+	walk = func(n *Node) {
+		if n.TrailingComma >= 0 {
+			out[n.TrailingComma] = ' '
+		}
+		for _, m := range n.Members {
+			walk(m.Value)
+		}
+		for _, e := range n.Elements {
+			walk(e)
+		}
+	}
+	walk(d.root)
+	return out
+}
+
+// indentUnit guesses one level of indentation from the root object
+func (d *Document) indentUnit() string {
+	if d.root.Kind == Object && len(d.root.Members) > 0 {
+		first := d.root.Members[0].KeyOffset
+		if d.onOwnLine(first) {
+			outer := d.lineIndent(d.root.Offset)
+			inner := d.lineIndent(first)
+			if strings.HasPrefix(inner, outer) && len(inner) > len(outer) {
+				return inner[len(outer):]
+			}
+		}
+	}
+	return defaultIndent
+}
+
+func (d *Document) lineStart(off int) int {
+	return bytes.LastIndexByte(d.src[:off], '\n') + 1
+}
+
+func (d *Document) lineIndent(off int) string {
+	start := d.lineStart(off)
+	end := start
+	for end < len(d.src) && (d.src[end] == ' ' || d.src[end] == '\t') {
+		end++
+	}
+	return string(d.src[start:end])
+}
+
+// onOwnLine reports whether only whitespace precedes off on its line
+func (d *Document) onOwnLine(off int) bool {
+	return len(bytes.TrimSpace(d.src[d.lineStart(off):off])) == 0
+}
+
+// restOfLineBlank reports whether only whitespace follows off on its line
+func (d *Document) restOfLineBlank(off int) bool {
+	return len(bytes.TrimSpace(d.src[off:d.nextLine(off)])) == 0
+}
+
+// nextLine returns the offset of the line after the one containing off
+func (d *Document) nextLine(off int) int {
+	if i := bytes.IndexByte(d.src[off:], '\n'); i >= 0 {
+		return off + i + 1
+	}
+	return len(d.src)
+}
+
+func findMember(obj *Node, key string) *Member {
+	if obj == nil || obj.Kind != Object {
+		return nil
+	}
+	// The last occurrence wins, matching encoding/json
+	for i := len(obj.Members) - 1; i >= 0; i-- {
+		if obj.Members[i].Key == key {
+			return obj.Members[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,383 @@
+// Package jsonc parses JSON with comments and trailing commas, the format
+// Cursor (like VS Code) uses for its settings and storage files.
+//
+// Parsing produces a Document that keeps the original source. Edits made
+// through the Document are applied as minimal text replacements, so
+// comments, key order and formatting outside the edited values survive a
+// round trip.
+package jsonc
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Kind identifies the type of a JSON value
+type Kind int
+
+const (
+	Null Kind = iota
+	Bool
+	Number
+	String
+	Object
+	Array
+)
+
+// String returns the name of the kind
+func (k Kind) String() string {
+	switch k {
+	case Null:
+		return "null"
+	case Bool:
+		return "bool"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Object:
+		return "object"
+	case Array:
+		return "array"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Node is a parsed JSON value with its byte range in the source
+type Node struct {
+	Kind   Kind
+	Offset int // Offset of the first byte of the value
+	End    int // Offset just past the last byte of the value
+
+	Members       []*Member // Object members in source order
+	Elements      []*Node   // Array elements in source order
+	TrailingComma int       // Offset of a trailing comma in an object or array, or -1
+}
+
+// Member is a key/value pair inside an object
+type Member struct {
+	Key       string
+	KeyOffset int // Offset of the opening quote of the key
+	Value     *Node
+	Comma     int // Offset of the comma following the value, or -1
+}
+
+// SyntaxError describes a parse failure and where it occurred
+type SyntaxError struct {
+	Offset int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("jsonc: %s at offset %d", e.Msg, e.Offset)
+}
+
+// maxDepth bounds nesting so hostile input cannot exhaust the stack
+const maxDepth = 1000
+
+type parser struct {
+	src   []byte
+	pos   int
+	depth int
+}
+
+// parse parses a complete JSONC text and returns its root value
+func parse(src []byte) (*Node, error) {
+	p := &parser{src: src}
+	if err := p.skipSpace(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.skipSpace(); err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q after top-level value", p.src[p.pos])
+	}
+	return root, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Offset: p.pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// skipSpace skips whitespace as well as line and block comments
+func (p *parser) skipSpace() error {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case c == 0xEF && p.pos == 0 && len(p.src) >= 3 && p.src[1] == 0xBB && p.src[2] == 0xBF:
+			p.pos += 3 // UTF-8 byte order mark
+		case c == '/' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '/':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		case c == '/' && p.pos+1 < len(p.src) && p.src[p.pos+1] == '*':
+			start := p.pos
+			p.pos += 2
+			for {
+				if p.pos+1 >= len(p.src) {
+					p.pos = start
+					return p.errorf("unterminated block comment")
+				}
+				if p.src[p.pos] == '*' && p.src[p.pos+1] == '/' {
+					p.pos += 2
+					break
+				}
+				p.pos++
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+func (p *parser) parseValue() (*Node, error) {
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of input")
+	}
+	switch c := p.src[p.pos]; {
+	case c == '{':
+		return p.parseObject()
+	case c == '[':
+		return p.parseArray()
+	case c == '"':
+		start := p.pos
+		if err := p.scanString(); err != nil {
+			return nil, err
+		}
+		return &Node{Kind: String, Offset: start, End: p.pos, TrailingComma: -1}, nil
+	case c == '-' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+	default:
+		for _, lit := range []struct {
+			word string
+			kind Kind
+		}{{"true", Bool}, {"false", Bool}, {"null", Null}} {
+			if p.hasPrefix(lit.word) {
+				start := p.pos
+				p.pos += len(lit.word)
+				return &Node{Kind: lit.kind, Offset: start, End: p.pos, TrailingComma: -1}, nil
+			}
+		}
+		return nil, p.errorf("unexpected %q", c)
+	}
+}
+
+func (p *parser) hasPrefix(word string) bool {
+	return len(p.src)-p.pos >= len(word) && string(p.src[p.pos:p.pos+len(word)]) == word
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return p.errorf("maximum nesting depth exceeded")
+	}
+	return nil
+}
+
+func (p *parser) parseObject() (*Node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	node := &Node{Kind: Object, Offset: p.pos, TrailingComma: -1}
+	p.pos++ // '{'
+
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated object")
+		}
+		if p.src[p.pos] == '}' {
+			p.pos++
+			node.End = p.pos
+			return node, nil
+		}
+		if len(node.Members) > 0 && node.Members[len(node.Members)-1].Comma < 0 {
+			return nil, p.errorf("expected ',' or '}' in object")
+		}
+		node.TrailingComma = -1
+
+		if p.src[p.pos] != '"' {
+			return nil, p.errorf("expected string key in object")
+		}
+		keyStart := p.pos
+		if err := p.scanString(); err != nil {
+			return nil, err
+		}
+		var key string
+		if err := json.Unmarshal(p.src[keyStart:p.pos], &key); err != nil {
+			p.pos = keyStart
+			return nil, p.errorf("invalid object key: %v", err)
+		}
+
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.src) || p.src[p.pos] != ':' {
+			return nil, p.errorf("expected ':' after object key")
+		}
+		p.pos++
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		member := &Member{Key: key, KeyOffset: keyStart, Value: value, Comma: -1}
+		node.Members = append(node.Members, member)
+
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			member.Comma = p.pos
+			node.TrailingComma = p.pos
+			p.pos++
+		}
+	}
+}
+
+func (p *parser) parseArray() (*Node, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+
+	node := &Node{Kind: Array, Offset: p.pos, TrailingComma: -1}
+	p.pos++ // '['
+	expectComma := false
+
+	for {
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.src) {
+			return nil, p.errorf("unterminated array")
+		}
+		if p.src[p.pos] == ']' {
+			p.pos++
+			node.End = p.pos
+			return node, nil
+		}
+		if expectComma {
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+		node.TrailingComma = -1
+
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		node.Elements = append(node.Elements, value)
+
+		if err := p.skipSpace(); err != nil {
+			return nil, err
+		}
+		expectComma = true
+		if p.pos < len(p.src) && p.src[p.pos] == ',' {
+			node.TrailingComma = p.pos
+			p.pos++
+			expectComma = false
+		}
+	}
+}
+
+// scanString advances past a double-quoted string, validating escapes
+func (p *parser) scanString() error {
+	start := p.pos
+	p.pos++ // opening quote
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; {
+		case c == '"':
+			p.pos++
+			return nil
+		case c == '\\':
+			if p.pos+1 >= len(p.src) {
+				p.pos = start
+				return p.errorf("unterminated string")
+			}
+			switch p.src[p.pos+1] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				p.pos += 2
+			case 'u':
+				if len(p.src)-p.pos < 6 || !isHex(p.src[p.pos+2:p.pos+6]) {
+					return p.errorf("invalid unicode escape in string")
+				}
+				p.pos += 6
+			default:
+				return p.errorf("invalid escape %q in string", p.src[p.pos+1])
+			}
+		case c < 0x20:
+			return p.errorf("control character in string")
+		default:
+			p.pos++
+		}
+	}
+	p.pos = start
+	return p.errorf("unterminated string")
+}
+
+func (p *parser) parseNumber() (*Node, error) {
+	start := p.pos
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	if p.pos >= len(p.src) || !isDigit(p.src[p.pos]) {
+		return nil, p.errorf("invalid number")
+	}
+	if p.src[p.pos] == '0' {
+		p.pos++
+	} else {
+		p.skipDigits()
+	}
+	if p.pos < len(p.src) && p.src[p.pos] == '.' {
+		p.pos++
+		if p.pos >= len(p.src) || !isDigit(p.src[p.pos]) {
+			return nil, p.errorf("invalid number")
+		}
+		p.skipDigits()
+	}
+	if p.pos < len(p.src) && (p.src[p.pos] == 'e' || p.src[p.pos] == 'E') {
+		p.pos++
+		if p.pos < len(p.src) && (p.src[p.pos] == '+' || p.src[p.pos] == '-') {
+			p.pos++
+		}
+		if p.pos >= len(p.src) || !isDigit(p.src[p.pos]) {
+			return nil, p.errorf("invalid number")
+		}
+		p.skipDigits()
+	}
+	return &Node{Kind: Number, Offset: start, End: p.pos, TrailingComma: -1}, nil
+}
+
+func (p *parser) skipDigits() {
+	for p.pos < len(p.src) && isDigit(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(b []byte) bool {
+	for _, c := range b {
+		if !isDigit(c) && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,239 @@
+package jsonc
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   string
+		path  []string
+		value interface{}
+		want  string
+	}{
+		{
+			name:  "replace keeps comments",
+			src:   "{\n    // id\n    \"a\": \"old\", /* keep */\n    \"b\": 1\n}",
+			path:  []string{"a"},
+			value: "new",
+			want:  "{\n    // id\n    \"a\": \"new\", /* keep */\n    \"b\": 1\n}",
+		},
+		{
+			name:  "append keeps key order",
+			src:   "{\n  \"z\": 1,\n  \"a\": 2\n}",
+			path:  []string{"m"},
+			value: 3,
+			want:  "{\n  \"z\": 1,\n  \"a\": 2,\n  \"m\": 3\n}",
+		},
+		{
+			name:  "append keeps trailing comma",
+			src:   "{\n    \"a\": 1,\n}",
+			path:  []string{"b"},
+			value: 2,
+			want:  "{\n    \"a\": 1,\n    \"b\": 2,\n}",
+		},
+		{
+			name:  "append after line comment",
+			src:   "{\n    \"a\": 1 // note\n}",
+			path:  []string{"b"},
+			value: 2,
+			want:  "{\n    \"a\": 1, // note\n    \"b\": 2\n}",
+		},
+		{
+			name:  "append after block comment before brace",
+			src:   "{\n    \"a\": 1 /* note */}",
+			path:  []string{"b"},
+			value: 2,
+			want:  "{\n    \"a\": 1, /* note */\n    \"b\": 2}",
+		},
+		{
+			name:  "append after comma and block comment before brace",
+			src:   "{\n    \"a\": 1, /* note */ }",
+			path:  []string{"b"},
+			value: 2,
+			want:  "{\n    \"a\": 1, /* note */\n    \"b\": 2}",
+		},
+		{
+			name:  "append on one line",
+			src:   `{"a": 1 /* note */}`,
+			path:  []string{"b"},
+			value: 2,
+			want:  `{"a": 1, "b": 2 /* note */}`,
+		},
+		{
+			name:  "append to empty object",
+			src:   "{}",
+			path:  []string{"a"},
+			value: true,
+			want:  "{\n    \"a\": true\n}",
+		},
+		{
+			name:  "create nested objects",
+			src:   "{\n  \"a\": {\n    \"x\": 1\n  }\n}",
+			path:  []string{"a", "y", "z"},
+			value: "v",
+			want:  "{\n  \"a\": {\n    \"x\": 1,\n    \"y\": {\n      \"z\": \"v\"\n    }\n  }\n}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.src))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if err := doc.Set(tt.value, tt.path...); err != nil {
+				t.Fatalf("Set: %v", err)
+			}
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		key  string
+		want string
+	}{
+		{
+			name: "middle member with its line",
+			src:  "{\n    // a\n    \"a\": 1,\n    \"b\": 2,\n    \"c\": 3 // c\n}",
+			key:  "b",
+			want: "{\n    // a\n    \"a\": 1,\n    \"c\": 3 // c\n}",
+		},
+		{
+			name: "same-line comment is kept",
+			src:  "{\n    \"a\": 1, /* a */ \"b\": 2\n}",
+			key:  "a",
+			want: "{\n    /* a */ \"b\": 2\n}",
+		},
+		{
+			name: "last member drops preceding comma",
+			src:  "{\n    // first\n    \"a\": 1,\n    \"b\": 2\n}",
+			key:  "b",
+			want: "{\n    // first\n    \"a\": 1\n}",
+		},
+		{
+			name: "last member with trailing comma",
+			src:  "{\n    \"a\": 1,\n    \"b\": 2,\n}",
+			key:  "b",
+			want: "{\n    \"a\": 1,\n}",
+		},
+		{
+			name: "missing key",
+			src:  "{\"a\": 1}",
+			key:  "x",
+			want: "{\"a\": 1}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := Parse([]byte(tt.src))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if err := doc.Delete(tt.key); err != nil {
+				t.Fatalf("Delete: %v", err)
+			}
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStandardize(t *testing.T) {
+	src := "\xEF\xBB\xBF{\n  // c\n  \"a\": \"// not a comment\", /* b */\n  \"z\": [1, 2,],\n}"
+	got, err := Standardize([]byte(src))
+	if err != nil {
+		t.Fatalf("Standardize: %v", err)
+	}
+	if len(got) != len(src) || strings.Count(string(got), "\n") != strings.Count(src, "\n") {
+		t.Errorf("offsets not preserved: %q", got)
+	}
+	var v struct {
+		A string `json:"a"`
+		Z []int  `json:"z"`
+	}
+	if err := json.Unmarshal(got, &v); err != nil {
+		t.Fatalf("result is not JSON: %v\n%s", err, got)
+	}
+	if v.A != "// not a comment" || len(v.Z) != 2 {
+		t.Errorf("got %+v", v)
+	}
+	// Key order is kept
+	if strings.Index(string(got), `"a"`) > strings.Index(string(got), `"z"`) {
+		t.Errorf("key order changed: %s", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, src := range []string{
+		"", "{", `{"a"}`, `{"a": 1 "b": 2}`, `[1 2]`, `{"a": 01}`, `"\x"`, `/* open`,
+		`{"a": 1}}`, strings.Repeat("[", maxDepth+1) + strings.Repeat("]", maxDepth+1),
+	} {
+		var syntaxErr *SyntaxError
+		if _, err := Parse([]byte(src)); !errors.As(err, &syntaxErr) {
+			t.Errorf("Parse(%.20q) = %v, want a *SyntaxError", src, err)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		`{}`, `[]`, `null`, `-0.5e+3`, `"é\n"`,
+		"{\n    \"telemetry.machineId\": \"abc\", // id\n    \"a\": [1, {\"b\": null},],\n}",
+		"\xEF\xBB\xBF{\"a\": 1 /* c */}",
+		`{"a": 1, "a": 2}`,
+	} {
+		f.Add([]byte(seed))
+	}
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := Parse(data)
+		if err != nil {
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("error is not a *SyntaxError: %v", err)
+			}
+			if json.Valid(data) && !strings.Contains(syntaxErr.Msg, "nesting depth") {
+				t.Fatalf("valid JSON rejected: %v", err)
+			}
+			return
+		}
+		if std := doc.standardize(); !json.Valid(std) {
+			t.Fatalf("standardized document is not JSON: %q", std)
+		}
+		if doc.Root().Kind != Object || doc.Lookup("fuzz") != nil {
+			return
+		}
+
+		if err := doc.Set(map[string]interface{}{"x": []int{1}}, "fuzz", "key"); err != nil {
+			t.Fatalf("Set: %v", err)
+		}
+		var got []int
+		if err := doc.Get(&got, "fuzz", "key", "x"); err != nil || len(got) != 1 {
+			t.Fatalf("Get after Set = %v, %v", got, err)
+		}
+		if _, err := Parse(doc.Bytes()); err != nil {
+			t.Fatalf("document does not parse after Set: %v", err)
+		}
+
+		for _, m := range doc.Root().Members {
+			if err := doc.Delete(m.Key); err != nil {
+				t.Fatalf("Delete(%q): %v\n%s", m.Key, err, doc.Bytes())
+			}
+			break
+		}
+		if _, err := Parse(doc.Bytes()); err != nil {
+			t.Fatalf("document does not parse after Delete: %v", err)
+		}
+	})
+}
@@ -0,0 +1,245 @@
+package lang
+
+import (
+	"bytes"
+	"embed"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Domain is the gettext domain of the tool's catalogs
+const Domain = "cursor-id-modifier"
+
+// contextSeparator joins msgctxt and msgid in catalog keys, as in .mo files
+const contextSeparator = "\x04"
+
+// locales holds the built-in catalogs: the template with the English
+// source strings and one .po file per translation
+//
+//go:embed locales
+var locales embed.FS
+
+// Message is a catalog entry
+type Message struct {
+	Context string
+	ID      string
+	Plural  string   // msgid_plural, empty for singular messages
+	Strs    []string // msgstr, or msgstr[n] for plural messages
+}
+
+// Catalog holds the messages of one language
+type Catalog struct {
+	messages map[string]*Message
+}
+
+// Lookup returns the message with the given context and msgid
+func (c *Catalog) Lookup(context, id string) (*Message, bool) {
+	if c == nil {
+		return nil, false
+	}
+	m, ok := c.messages[catalogKey(context, id)]
+	return m, ok
+}
+
+// Messages returns every message in the catalog
+func (c *Catalog) Messages() []*Message {
+	messages := make([]*Message, 0, len(c.messages))
+	for _, m := range c.messages {
+		messages = append(messages, m)
+	}
+	return messages
+}
+
+func catalogKey(context, id string) string {
+	if context == "" {
+		return id
+	}
+	return context + contextSeparator + id
+}
+
+// add stores m unless it is the header entry
+func (c *Catalog) add(m *Message) {
+	if m.ID == "" {
+		return
+	}
+	c.messages[catalogKey(m.Context, m.ID)] = m
+}
+
+// LoadCatalog reads a .po or .mo file, chosen by extension
+func LoadCatalog(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read catalog: %w", err)
+	}
+	var catalog *Catalog
+	if strings.EqualFold(filepath.Ext(path), ".mo") {
+		catalog, err = ParseMO(data)
+	} else {
+		catalog, err = ParsePO(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return catalog, nil
+}
+
+// ParsePO parses a gettext .po file
+func ParsePO(data []byte) (*Catalog, error) {
+	catalog := &Catalog{messages: make(map[string]*Message)}
+
+	var current *Message
+	var target *string // String that continuation lines append to
+	flush := func() {
+		if current != nil {
+			catalog.add(current)
+		}
+		current, target = nil, nil
+	}
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i, raw := range lines {
+		line := strings.TrimSpace(raw)
+		switch {
+		case line == "":
+			flush()
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, `"`):
+			if target == nil {
+				return nil, fmt.Errorf("line %d: string without keyword", i+1)
+			}
+			s, err := unquotePO(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			*target += s
+			continue
+		}
+
+		keyword, value, _ := strings.Cut(line, " ")
+		s, err := unquotePO(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		// msgctxt or msgid after a complete entry starts the next one
+		if (keyword == "msgctxt" || keyword == "msgid") && current != nil && len(current.Strs) > 0 {
+			flush()
+		}
+		if current == nil {
+			current = &Message{}
+		}
+
+		switch {
+		case keyword == "msgctxt":
+			current.Context = s
+			target = &current.Context
+		case keyword == "msgid":
+			current.ID = s
+			target = &current.ID
+		case keyword == "msgid_plural":
+			current.Plural = s
+			target = &current.Plural
+		case keyword == "msgstr":
+			current.Strs = append(current.Strs, s)
+			target = &current.Strs[len(current.Strs)-1]
+		case strings.HasPrefix(keyword, "msgstr[") && strings.HasSuffix(keyword, "]"):
+			n, err := strconv.Atoi(keyword[len("msgstr[") : len(keyword)-1])
+			if err != nil || n != len(current.Strs) {
+				return nil, fmt.Errorf("line %d: unexpected %s", i+1, keyword)
+			}
+			current.Strs = append(current.Strs, s)
+			target = &current.Strs[n]
+		default:
+			return nil, fmt.Errorf("line %d: unknown keyword %q", i+1, keyword)
+		}
+	}
+	flush()
+	return catalog, nil
+}
+
+// unquotePO decodes a C-style quoted string
+func unquotePO(s string) (string, error) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", fmt.Errorf("expected quoted string, got %q", s)
+	}
+	value, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", s)
+	}
+	return value, nil
+}
+
+// ParseMO parses a compiled gettext .mo file of either byte order
+func ParseMO(data []byte) (*Catalog, error) {
+	if len(data) < 28 {
+		return nil, fmt.Errorf("file too short for a .mo catalog")
+	}
+
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(data) {
+	case 0x950412de:
+		order = binary.LittleEndian
+	case 0xde120495:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("not a .mo catalog")
+	}
+
+	// Offsets are compared as uint64 so that they cannot wrap around an int
+	size := uint64(len(data))
+	count := uint64(order.Uint32(data[8:]))
+	originals := uint64(order.Uint32(data[12:]))
+	translations := uint64(order.Uint32(data[16:]))
+	if count > size/16 {
+		return nil, fmt.Errorf("string count out of range")
+	}
+
+	str := func(table, i uint64) (string, error) {
+		entry := table + 8*i
+		if entry+8 > size {
+			return "", fmt.Errorf("string table out of range")
+		}
+		length := uint64(order.Uint32(data[entry:]))
+		offset := uint64(order.Uint32(data[entry+4:]))
+		if offset+length > size {
+			return "", fmt.Errorf("string out of range")
+		}
+		return string(data[offset : offset+length]), nil
+	}
+
+	catalog := &Catalog{messages: make(map[string]*Message, count)}
+	for i := uint64(0); i < count; i++ {
+		original, err := str(originals, i)
+		if err != nil {
+			return nil, err
+		}
+		translation, err := str(translations, i)
+		if err != nil {
+			return nil, err
+		}
+
+		m := &Message{}
+		if ctx, rest, ok := strings.Cut(original, contextSeparator); ok {
+			m.Context, original = ctx, rest
+		}
+		m.ID, m.Plural, _ = strings.Cut(original, "\x00")
+		m.Strs = strings.Split(translation, "\x00")
+		catalog.add(m)
+	}
+	return catalog, nil
+}
+
+// embeddedCatalog reads a built-in catalog
+func embeddedCatalog(name string) (*Catalog, error) {
+	data, err := locales.ReadFile("locales/" + name)
+	if err != nil {
+		return nil, err
+	}
+	return ParsePO(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+}
@@ -0,0 +1,81 @@
+package lang
+
+import (
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+// buildMO returns a .mo file in byte order holding the msgid/msgstr pairs,
+// with msgctxt joined to msgid by contextSeparator
+func buildMO(order binary.ByteOrder, pairs ...[2]string) []byte {
+	n := len(pairs)
+	originals, translations := 28, 28+8*n
+	data := make([]byte, 28+16*n)
+	order.PutUint32(data, 0x950412de)
+	order.PutUint32(data[8:], uint32(n))
+	order.PutUint32(data[12:], uint32(originals))
+	order.PutUint32(data[16:], uint32(translations))
+	for i, pair := range pairs {
+		for j, table := range []int{originals, translations} {
+			entry := table + 8*i
+			order.PutUint32(data[entry:], uint32(len(pair[j])))
+			order.PutUint32(data[entry+4:], uint32(len(data)))
+			data = append(data, pair[j]...)
+		}
+	}
+	return data
+}
+
+func TestParseMO(t *testing.T) {
+	for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+		data := buildMO(order,
+			[2]string{"Success" + contextSeparator + "Done", "Fertig"},
+			[2]string{"Files" + contextSeparator + "one file\x00{count} files", "eine Datei\x00{count} Dateien"},
+		)
+		catalog, err := ParseMO(data)
+		if err != nil {
+			t.Fatalf("%v: %v", order, err)
+		}
+
+		m, ok := catalog.Lookup("Success", "Done")
+		if !ok || !reflect.DeepEqual(m.Strs, []string{"Fertig"}) {
+			t.Errorf("%v: Success = %+v, %v", order, m, ok)
+		}
+		m, ok = catalog.Lookup("Files", "one file")
+		want := &Message{Context: "Files", ID: "one file", Plural: "{count} files", Strs: []string{"eine Datei", "{count} Dateien"}}
+		if !ok || !reflect.DeepEqual(m, want) {
+			t.Errorf("%v: Files = %+v, want %+v", order, m, want)
+		}
+	}
+}
+
+func TestParseMOInvalid(t *testing.T) {
+	valid := buildMO(binary.LittleEndian, [2]string{"Done", "Fertig"})
+	// patched returns valid with the 32-bit value at offset replaced by v
+	patched := func(offset int, v uint32) []byte {
+		data := append([]byte(nil), valid...)
+		binary.LittleEndian.PutUint32(data[offset:], v)
+		return data
+	}
+
+	tests := map[string][]byte{
+		"too short":                  valid[:27],
+		"bad magic":                  patched(0, 0x12345678),
+		"count beyond the file":      patched(8, 2),
+		"huge count":                 patched(8, 0xffffffff),
+		"table beyond the file":      patched(12, uint32(len(valid))),
+		"table offset wraps":         patched(12, 0xfffffffc),
+		"string beyond the file":     patched(28, uint32(len(valid))),
+		"string offset wraps":        patched(32, 0xfffffff0),
+		"string length wraps":        patched(28, 0xffffffff),
+		"translation beyond the end": patched(36, 0x7fffffff),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if catalog, err := ParseMO(data); err == nil {
+				t.Errorf("parsed %v, want an error", catalog.Messages())
+			}
+		})
+	}
+}
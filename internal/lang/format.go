@@ -0,0 +1,89 @@
+package lang
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Args are the values substituted for a message's {name} placeholders
+type Args map[string]interface{}
+
+// CountArg is the argument that selects the form of plural messages
+const CountArg = "count"
+
+// T returns the message named key in the current language with args
+// substituted for its placeholders. Plural messages choose their form by
+// args["count"]. Unknown keys are returned as they are.
+func T(key string, args ...Args) string {
+	merged := Args{}
+	for _, a := range args {
+		for name, value := range a {
+			merged[name] = value
+		}
+	}
+	return format(lookup(GetCurrentLanguage(), key, merged), merged)
+}
+
+// format replaces each {name} in message with args[name]. Placeholders
+// without an argument are left as they are.
+func format(message string, args Args) string {
+	if len(args) == 0 || !strings.Contains(message, "{") {
+		return message
+	}
+
+	var b strings.Builder
+	for {
+		start := strings.IndexByte(message, '{')
+		if start < 0 {
+			break
+		}
+		end := strings.IndexByte(message[start:], '}')
+		if end < 0 {
+			break
+		}
+		end += start
+		b.WriteString(message[:start])
+		if value, ok := args[message[start+1:end]]; ok {
+			fmt.Fprint(&b, value)
+		} else {
+			b.WriteString(message[start : end+1])
+		}
+		message = message[end+1:]
+	}
+	b.WriteString(message)
+	return b.String()
+}
+
+// count converts the plural operand to an integer. Negative numbers use
+// their absolute value; other types are not counts.
+func count(value interface{}) (uint64, bool) {
+	var n int64
+	switch v := value.(type) {
+	case int:
+		n = int64(v)
+	case int8:
+		n = int64(v)
+	case int16:
+		n = int64(v)
+	case int32:
+		n = int64(v)
+	case int64:
+		n = v
+	case uint:
+		return uint64(v), true
+	case uint8:
+		return uint64(v), true
+	case uint16:
+		return uint64(v), true
+	case uint32:
+		return uint64(v), true
+	case uint64:
+		return v, true
+	default:
+		return 0, false
+	}
+	if n < 0 {
+		return uint64(-n), true
+	}
+	return uint64(n), true
+}
@@ -0,0 +1,79 @@
+package lang
+
+import (
+	"os"
+	"strings"
+	"sync"
+)
+
+// LocaleSource supplies the locales the language is detected from
+type LocaleSource struct {
+	Getenv func(key string) string // Looks up environment variables
+	System func() []string         // Returns the OS UI languages, most preferred first
+}
+
+// localeSource is used when the language is detected
+var localeSource = DefaultLocaleSource()
+
+// DefaultLocaleSource reads the process environment and the operating
+// system's settings without running other programs. The system locales
+// are read once and cached.
+func DefaultLocaleSource() LocaleSource {
+	var (
+		once    sync.Once
+		locales []string
+	)
+	return LocaleSource{
+		Getenv: os.Getenv,
+		System: func() []string {
+			once.Do(func() { locales = systemLocales() })
+			return locales
+		},
+	}
+}
+
+// SetLocaleSource replaces where the language is detected from and forgets
+// the current language, so that the next GetCurrentLanguage detects it again
+func SetLocaleSource(src LocaleSource) {
+	languageMutex.Lock()
+	defer languageMutex.Unlock()
+	localeSource = src
+	currentLanguage = ""
+}
+
+// detectLanguage negotiates the language from the locale environment
+// variables, then the operating system's UI languages
+func detectLanguage(src LocaleSource) Language {
+	if src.Getenv != nil {
+		if lang, ok := Negotiate(envLocales(src.Getenv)); ok {
+			return lang
+		}
+	}
+	if src.System != nil {
+		if lang, ok := Negotiate(src.System()); ok {
+			return lang
+		}
+	}
+	return EN
+}
+
+// envLocales returns the locales requested by the environment, most
+// preferred first. As in gettext, the LANGUAGE list comes before LC_ALL,
+// LC_MESSAGES and LANG, and is ignored when the locale is explicitly C.
+func envLocales(getenv func(string) string) []string {
+	var locale string
+	for _, envVar := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if locale = getenv(envVar); locale != "" {
+			break
+		}
+	}
+
+	var locales []string
+	if language := getenv("LANGUAGE"); language != "" && !isPOSIXLocale(locale) {
+		locales = strings.Split(language, ":")
+	}
+	if locale != "" {
+		locales = append(locales, locale)
+	}
+	return locales
+}
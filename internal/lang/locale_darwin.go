@@ -0,0 +1,42 @@
+package lang
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/yuaotian/go-cursor-help/internal/paths"
+)
+
+// systemLocales returns the languages from System Settings, read from the
+// user's global preferences. Under sudo these are the invoking user's.
+func systemLocales() []string {
+	home, err := os.UserHomeDir()
+	if username := os.Getenv("SUDO_USER"); username != "" {
+		home, err = paths.HomeDir(username)
+	}
+	if err != nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, "Library", "Preferences", ".GlobalPreferences.plist"))
+	if err != nil {
+		return nil
+	}
+	prefs, err := parseBinaryPlist(data)
+	if err != nil {
+		return nil
+	}
+
+	var locales []string
+	if languages, ok := prefs["AppleLanguages"].([]interface{}); ok {
+		for _, l := range languages {
+			if s, ok := l.(string); ok {
+				locales = append(locales, s)
+			}
+		}
+	}
+	if locale, ok := prefs["AppleLocale"].(string); ok {
+		locales = append(locales, locale)
+	}
+	return locales
+}
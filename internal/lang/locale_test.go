@@ -0,0 +1,59 @@
+package lang
+
+import "testing"
+
+// testSource returns a LocaleSource reading env and the system languages
+func testSource(env map[string]string, system ...string) LocaleSource {
+	return LocaleSource{
+		Getenv: func(key string) string { return env[key] },
+		System: func() []string { return system },
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    map[string]string
+		system []string
+		want   Language
+	}{
+		{"LANGUAGE list first", map[string]string{"LANGUAGE": "fr:ja:de", "LANG": "ru_RU.UTF-8"}, nil, JA},
+		{"LANGUAGE ignored for C", map[string]string{"LANGUAGE": "de", "LC_ALL": "C", "LANG": "ja_JP.UTF-8"}, []string{"es-ES"}, ES},
+		{"LANGUAGE ignored for POSIX", map[string]string{"LANGUAGE": "de", "LC_ALL": "POSIX"}, nil, EN},
+		{"LC_ALL over LC_MESSAGES", map[string]string{"LC_ALL": "de_DE.UTF-8", "LC_MESSAGES": "ja_JP", "LANG": "ru_RU"}, nil, DE},
+		{"LC_MESSAGES over LANG", map[string]string{"LC_MESSAGES": "ja_JP.UTF-8", "LANG": "ru_RU.UTF-8"}, nil, JA},
+		{"LANG", map[string]string{"LANG": "ru_RU.UTF-8"}, nil, RU},
+		{"system languages", nil, []string{"fr-FR", "zh-Hant-TW", "ja-JP"}, TW},
+		{"unsupported environment", map[string]string{"LANG": "fr_FR.UTF-8"}, []string{"zh-Hans-CN"}, CN},
+		{"nothing supported", map[string]string{"LANG": "fr_FR.UTF-8"}, []string{"it-IT"}, EN},
+		{"nothing set", nil, nil, EN},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectLanguage(testSource(tt.env, tt.system...)); got != tt.want {
+				t.Errorf("detectLanguage = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectLanguageWithoutSources(t *testing.T) {
+	if got := detectLanguage(LocaleSource{}); got != EN {
+		t.Errorf("detectLanguage = %s, want %s", got, EN)
+	}
+}
+
+func TestSetLocaleSource(t *testing.T) {
+	t.Cleanup(func() { SetLocaleSource(DefaultLocaleSource()) })
+
+	SetLanguage(DE)
+	SetLocaleSource(testSource(map[string]string{"LANG": "ja_JP.UTF-8"}))
+	if got := GetCurrentLanguage(); got != JA {
+		t.Errorf("GetCurrentLanguage = %s, want %s detected from the new source", got, JA)
+	}
+
+	SetLocaleSource(testSource(nil, "ru-RU"))
+	if got := GetCurrentLanguage(); got != RU {
+		t.Errorf("GetCurrentLanguage = %s, want %s", got, RU)
+	}
+}
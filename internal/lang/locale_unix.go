@@ -0,0 +1,45 @@
+//go:build !windows && !darwin
+
+package lang
+
+import (
+	"os"
+	"strings"
+)
+
+// localeFiles hold the system-wide locale on systemd and Debian systems
+var localeFiles = []string{"/etc/locale.conf", "/etc/default/locale"}
+
+// systemLocales returns the system-wide locale, which applies when the
+// environment sets none, e.g. under sudo or in a service
+func systemLocales() []string {
+	for _, path := range localeFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if locales := parseLocaleFile(string(data)); len(locales) > 0 {
+			return locales
+		}
+	}
+	return nil
+}
+
+// parseLocaleFile returns LC_MESSAGES and LANG from a KEY=value file
+func parseLocaleFile(data string) []string {
+	values := make(map[string]string)
+	for _, line := range strings.Split(data, "\n") {
+		name, value, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && !strings.HasPrefix(name, "#") {
+			values[strings.TrimSpace(name)] = strings.Trim(strings.TrimSpace(value), `"'`)
+		}
+	}
+
+	var locales []string
+	for _, name := range []string{"LC_MESSAGES", "LANG"} {
+		if value := values[name]; value != "" {
+			locales = append(locales, value)
+		}
+	}
+	return locales
+}
@@ -0,0 +1,12 @@
+package lang
+
+import "golang.org/x/sys/windows"
+
+// systemLocales returns the user's preferred UI languages
+func systemLocales() []string {
+	locales, err := windows.GetUserPreferredUILanguages(windows.MUI_LANGUAGE_NAME)
+	if err != nil {
+		return nil
+	}
+	return locales
+}
@@ -0,0 +1,164 @@
+package lang
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// localeNames maps each language to the directory of its catalog. English
+// is the source language and needs no catalog.
+var localeNames = map[Language]string{
+	CN: "zh_CN",
+	TW: "zh_TW",
+	EN: "en",
+	JA: "ja",
+	RU: "ru",
+	DE: "de",
+	ES: "es",
+}
+
+var (
+	catalogMu  sync.Mutex
+	catalogDir string
+	catalogs   = make(map[Language][]*Catalog)
+	source     map[string]*Message // English template by msgctxt
+)
+
+// SetCatalogDir makes the catalogs in dir, at
+// <locale>/LC_MESSAGES/<Domain>.mo or .po, take precedence over the built-in
+// ones. Messages they lack still come from the built-in catalogs.
+func SetCatalogDir(dir string) {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	catalogDir = dir
+	catalogs = make(map[Language][]*Catalog)
+}
+
+// lookup returns the message named key in lang, in the plural form for
+// args["count"], falling back to its English text and then to the key
+func lookup(lang Language, key string, args Args) string {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	source, ok := loadSource()[key]
+	if !ok {
+		return key
+	}
+	n, hasCount := count(args[CountArg])
+	plural := source.Plural != "" && hasCount
+
+	for _, catalog := range catalogsFor(lang) {
+		m, ok := catalog.Lookup(key, source.ID)
+		if !ok {
+			continue
+		}
+		i := 0
+		if plural {
+			i = pluralIndex(lang, n)
+		}
+		if i < len(m.Strs) && m.Strs[i] != "" {
+			return m.Strs[i]
+		}
+	}
+	if plural && pluralIndex(EN, n) > 0 {
+		return source.Plural
+	}
+	return source.ID
+}
+
+// loadSource indexes the English template by msgctxt. The template is
+// embedded, so failing to parse it is a build error.
+func loadSource() map[string]*Message {
+	if source != nil {
+		return source
+	}
+	template, err := embeddedCatalog(Domain + ".pot")
+	if err != nil {
+		panic(fmt.Sprintf("lang: invalid message template: %v", err))
+	}
+	source = make(map[string]*Message)
+	for _, m := range template.Messages() {
+		source[m.Context] = m
+	}
+	return source
+}
+
+// catalogsFor returns the catalogs of lang in lookup order: the one from
+// the catalog directory, then the built-in one
+func catalogsFor(lang Language) []*Catalog {
+	if loaded, ok := catalogs[lang]; ok {
+		return loaded
+	}
+
+	locale := localeNames[lang]
+	var loaded []*Catalog
+	if catalogDir != "" && locale != "" {
+		for _, ext := range []string{".mo", ".po"} {
+			path := filepath.Join(catalogDir, locale, "LC_MESSAGES", Domain+ext)
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+			catalog, err := LoadCatalog(path)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, "Warning:", err)
+				continue
+			}
+			loaded = append(loaded, catalog)
+			break
+		}
+	}
+	// English has no built-in catalog and uses the msgids as they are
+	if catalog, err := embeddedCatalog(locale + "/LC_MESSAGES/" + Domain + ".po"); err == nil {
+		loaded = append(loaded, catalog)
+	}
+
+	catalogs[lang] = loaded
+	return loaded
+}
+
+// Missing returns the names of the template messages that lang shows in
+// English, sorted: those without a translation or, for plural messages,
+// without every form lang needs
+func Missing(lang Language) []string {
+	if lang == EN {
+		return nil
+	}
+
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+
+	var missing []string
+	for key, m := range loadSource() {
+		forms := 1
+		if m.Plural != "" {
+			forms = len(ruleFor(lang).categories)
+		}
+		if !translated(catalogsFor(lang), m, forms) {
+			missing = append(missing, key)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// translated reports whether one of catalogs has forms non-empty msgstr
+// for m
+func translated(catalogs []*Catalog, m *Message, forms int) bool {
+	for _, catalog := range catalogs {
+		t, ok := catalog.Lookup(m.Context, m.ID)
+		if !ok || len(t.Strs) < forms {
+			continue
+		}
+		complete := true
+		for _, str := range t.Strs[:forms] {
+			complete = complete && str != ""
+		}
+		if complete {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,89 @@
+package lang
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestCatalogsComplete(t *testing.T) {
+	for _, l := range Supported() {
+		if missing := Missing(l); len(missing) > 0 {
+			t.Errorf("%s: missing translations for %v", l, missing)
+		}
+	}
+}
+
+var placeholderPattern = regexp.MustCompile(`\{[a-z]+\}`)
+
+// placeholders returns the distinct {name} placeholders in s, sorted
+func placeholders(s string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, p := range placeholderPattern.FindAllString(s, -1) {
+		if !seen[p] {
+			seen[p] = true
+			names = append(names, p)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestCatalogPlaceholders(t *testing.T) {
+	template := loadSource()
+	for _, l := range Supported() {
+		if l == EN {
+			continue
+		}
+		catalog, err := embeddedCatalog(localeNames[l] + "/LC_MESSAGES/" + Domain + ".po")
+		if err != nil {
+			t.Fatalf("%s: %v", l, err)
+		}
+
+		for _, m := range catalog.Messages() {
+			if m.Context == "" {
+				continue // Header
+			}
+			source, ok := template[m.Context]
+			if !ok {
+				t.Errorf("%s: %s is not in the template", l, m.Context)
+				continue
+			}
+			if m.ID != source.ID || m.Plural != source.Plural {
+				t.Errorf("%s: %s has msgid %q, template has %q", l, m.Context, m.ID, source.ID)
+			}
+
+			want := placeholders(source.ID + source.Plural)
+			for i, str := range m.Strs {
+				if got := placeholders(str); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: %s form %d uses %v, template uses %v", l, m.Context, i, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestTemplatePlaceholders(t *testing.T) {
+	for key, m := range loadSource() {
+		if m.Plural == "" {
+			continue
+		}
+		if got := placeholders(m.Plural); !reflect.DeepEqual(got, placeholders(m.ID)) {
+			t.Errorf("%s: plural uses %v, singular uses %v", key, got, placeholders(m.ID))
+		}
+		if !contains(placeholders(m.Plural), "{"+CountArg+"}") {
+			t.Errorf("%s: plural message without {%s}", key, CountArg)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package lang
+
+import "strings"
+
+// Negotiate returns the first supported language among locales, which may
+// be BCP 47 tags ("zh-Hant-TW") or POSIX locale names ("zh_TW.UTF-8"),
+// most preferred first
+func Negotiate(locales []string) (Language, bool) {
+	for _, locale := range locales {
+		if lang, ok := Match(locale); ok {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// Match maps a locale to the supported language that serves it best. Chinese
+// is split by script, using the region when no script is given; other
+// languages match on the primary language subtag. The legacy name "cn" is
+// accepted for Simplified Chinese.
+func Match(locale string) (Language, bool) {
+	subtags := parseTag(locale)
+	if len(subtags) == 0 {
+		return "", false
+	}
+
+	switch subtags[0] {
+	case "cn":
+		return CN, len(subtags) == 1
+	case "zh":
+		for _, subtag := range subtags[1:] {
+			switch subtag {
+			case "hant", "tw", "hk", "mo":
+				return TW, true
+			case "hans", "cn", "sg", "my":
+				return CN, true
+			}
+		}
+		return CN, true
+	}
+
+	for _, lang := range Supported() {
+		if string(lang) == subtags[0] {
+			return lang, true
+		}
+	}
+	return "", false
+}
+
+// parseTag lowercases a locale and splits it into subtags, dropping the
+// POSIX encoding and modifier ("de_DE.UTF-8@euro" becomes de, de)
+func parseTag(locale string) []string {
+	locale = strings.TrimSpace(locale)
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if isPOSIXLocale(locale) {
+		return nil
+	}
+	return strings.FieldsFunc(strings.ToLower(locale), func(r rune) bool {
+		return r == '-' || r == '_'
+	})
+}
+
+// isPOSIXLocale reports whether locale is the untranslated C locale
+func isPOSIXLocale(locale string) bool {
+	return locale == "C" || locale == "POSIX" || strings.HasPrefix(locale, "C.")
+}
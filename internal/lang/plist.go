@@ -0,0 +1,141 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"unicode/utf16"
+)
+
+// bplist is a binary property list being decoded
+type bplist struct {
+	data    []byte
+	offsets []uint64
+	refSize int
+}
+
+// parseBinaryPlist decodes the top-level dictionary of a bplist00 file.
+// Only strings, arrays and dictionaries are decoded; other values are nil.
+func parseBinaryPlist(data []byte) (map[string]interface{}, error) {
+	if len(data) < 40 || !bytes.HasPrefix(data, []byte("bplist00")) {
+		return nil, fmt.Errorf("not a binary plist")
+	}
+	trailer := data[len(data)-32:]
+	offsetSize, refSize := int(trailer[6]), int(trailer[7])
+	count := binary.BigEndian.Uint64(trailer[8:16])
+	top := binary.BigEndian.Uint64(trailer[16:24])
+	tableOffset := binary.BigEndian.Uint64(trailer[24:32])
+	if offsetSize == 0 || refSize == 0 || count > uint64(len(data)) || tableOffset > uint64(len(data)) ||
+		tableOffset+count*uint64(offsetSize) > uint64(len(data)) {
+		return nil, fmt.Errorf("invalid plist trailer")
+	}
+
+	p := &bplist{data: data, refSize: refSize, offsets: make([]uint64, count)}
+	for i := range p.offsets {
+		start := tableOffset + uint64(i*offsetSize)
+		p.offsets[i] = readUint(data[start : start+uint64(offsetSize)])
+	}
+	dict, ok := p.object(top, 0).(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("top-level value is not a dictionary")
+	}
+	return dict, nil
+}
+
+// object decodes the object with the given reference
+func (p *bplist) object(ref uint64, depth int) interface{} {
+	if ref >= uint64(len(p.offsets)) || depth > 16 {
+		return nil
+	}
+	pos := p.offsets[ref]
+	if pos >= uint64(len(p.data)) {
+		return nil
+	}
+	marker := p.data[pos]
+	n, pos, ok := p.length(marker, pos+1)
+	// No object has more elements than the file has bytes; checking this
+	// first keeps pos+2*n and the like from overflowing
+	if !ok || n > uint64(len(p.data)) {
+		return nil
+	}
+
+	switch marker >> 4 {
+	case 0x5: // ASCII string
+		if pos+n > uint64(len(p.data)) {
+			return nil
+		}
+		return string(p.data[pos : pos+n])
+	case 0x6: // UTF-16BE string
+		if pos+2*n > uint64(len(p.data)) {
+			return nil
+		}
+		units := make([]uint16, n)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(p.data[pos+uint64(2*i):])
+		}
+		return string(utf16.Decode(units))
+	case 0xA: // array
+		refs, ok := p.refs(pos, n)
+		if !ok {
+			return nil
+		}
+		items := make([]interface{}, len(refs))
+		for i, r := range refs {
+			items[i] = p.object(r, depth+1)
+		}
+		return items
+	case 0xD: // dictionary
+		refs, ok := p.refs(pos, 2*n)
+		if !ok {
+			return nil
+		}
+		dict := make(map[string]interface{}, n)
+		for i := uint64(0); i < n; i++ {
+			if key, ok := p.object(refs[i], depth+1).(string); ok {
+				dict[key] = p.object(refs[n+i], depth+1)
+			}
+		}
+		return dict
+	}
+	return nil
+}
+
+// length returns the element count of a string, array or dictionary whose
+// marker was read, and the position of its contents. Counts of 15 or more
+// follow the marker as an integer object.
+func (p *bplist) length(marker byte, pos uint64) (uint64, uint64, bool) {
+	if n := uint64(marker & 0x0F); n != 0x0F {
+		return n, pos, true
+	}
+	if pos >= uint64(len(p.data)) || p.data[pos]>>4 != 0x1 {
+		return 0, 0, false
+	}
+	size := uint64(1) << (p.data[pos] & 0x0F)
+	if pos+1+size > uint64(len(p.data)) {
+		return 0, 0, false
+	}
+	return readUint(p.data[pos+1 : pos+1+size]), pos + 1 + size, true
+}
+
+// refs reads n object references starting at pos
+func (p *bplist) refs(pos, n uint64) ([]uint64, bool) {
+	size := uint64(p.refSize)
+	if n > uint64(len(p.data)) || pos+n*size > uint64(len(p.data)) {
+		return nil, false
+	}
+	refs := make([]uint64, n)
+	for i := range refs {
+		start := pos + uint64(i)*size
+		refs[i] = readUint(p.data[start : start+size])
+	}
+	return refs, true
+}
+
+// readUint decodes a big-endian unsigned integer of up to 8 bytes
+func readUint(b []byte) uint64 {
+	var n uint64
+	for _, c := range b {
+		n = n<<8 | uint64(c)
+	}
+	return n
+}
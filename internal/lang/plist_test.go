@@ -0,0 +1,167 @@
+package lang
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+// Encoders for the objects of a test plist. References are one byte.
+
+func plistASCII(s string) []byte {
+	return append(plistMarker(0x5, uint64(len(s))), s...)
+}
+
+func plistUTF16(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	b := plistMarker(0x6, uint64(len(units)))
+	for _, u := range units {
+		b = binary.BigEndian.AppendUint16(b, u)
+	}
+	return b
+}
+
+func plistArray(refs ...byte) []byte {
+	return append(plistMarker(0xA, uint64(len(refs))), refs...)
+}
+
+// plistDict takes the key references followed by the value references
+func plistDict(refs ...byte) []byte {
+	return append(plistMarker(0xD, uint64(len(refs)/2)), refs...)
+}
+
+// plistMarker encodes an object marker with its element count, using a
+// following 8-byte integer for counts of 15 or more
+func plistMarker(kind byte, n uint64) []byte {
+	if n < 15 {
+		return []byte{kind<<4 | byte(n)}
+	}
+	return binary.BigEndian.AppendUint64([]byte{kind<<4 | 0xF, 0x13}, n)
+}
+
+// buildPlist lays out objects with a two-byte offset table and the trailer
+func buildPlist(top uint64, objects ...[]byte) []byte {
+	data := []byte("bplist00")
+	var offsets []uint64
+	for _, obj := range objects {
+		offsets = append(offsets, uint64(len(data)))
+		data = append(data, obj...)
+	}
+	tableOffset := uint64(len(data))
+	for _, off := range offsets {
+		data = binary.BigEndian.AppendUint16(data, uint16(off))
+	}
+	trailer := make([]byte, 32)
+	trailer[6], trailer[7] = 2, 1
+	binary.BigEndian.PutUint64(trailer[8:], uint64(len(objects)))
+	binary.BigEndian.PutUint64(trailer[16:], top)
+	binary.BigEndian.PutUint64(trailer[24:], tableOffset)
+	return append(data, trailer...)
+}
+
+// globalPreferences is a .GlobalPreferences.plist reduced to the keys read
+func globalPreferences() []byte {
+	return buildPlist(0,
+		plistDict(1, 2, 3, 6),              // 0
+		plistASCII("AppleLanguages"),       // 1
+		plistASCII("AppleLocale"),          // 2
+		plistArray(4, 5, 7),                // 3
+		plistASCII("zh-Hant-TW"),           // 4
+		plistUTF16("ja-JP"),                // 5
+		plistASCII("en_US@currency=EUR"),   // 6, longer than 14 bytes
+		plistUTF16("Deutsch (Österreich)"), // 7, non-ASCII and long
+	)
+}
+
+func TestParseBinaryPlist(t *testing.T) {
+	got, err := parseBinaryPlist(globalPreferences())
+	if err != nil {
+		t.Fatalf("parseBinaryPlist: %v", err)
+	}
+	want := map[string]interface{}{
+		"AppleLanguages": []interface{}{"zh-Hant-TW", "ja-JP", "Deutsch (Österreich)"},
+		"AppleLocale":    "en_US@currency=EUR",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %#v\nwant %#v", got, want)
+	}
+}
+
+func TestParseBinaryPlistTruncated(t *testing.T) {
+	data := globalPreferences()
+	for n := 0; n < len(data); n++ {
+		// Must not panic; the trailer is gone, so most prefixes are rejected
+		parseBinaryPlist(data[:n])
+	}
+
+	// Cutting into the objects while keeping the trailer drops the values
+	// that no longer fit instead of reading past the end
+	body := bytes.Clone(data)
+	for i := len("bplist00") + 20; i < len(body)-32; i++ {
+		body[i] = 0xFF
+	}
+	parseBinaryPlist(body)
+}
+
+func TestParseBinaryPlistInvalid(t *testing.T) {
+	valid := globalPreferences()
+	withTrailer := func(offset int, value uint64) []byte {
+		data := bytes.Clone(valid)
+		binary.BigEndian.PutUint64(data[len(data)-32+offset:], value)
+		return data
+	}
+
+	for name, data := range map[string][]byte{
+		"empty":               nil,
+		"XML plist":           []byte(`<?xml version="1.0"?><plist version="1.0"><dict/></plist>` + string(make([]byte, 40))),
+		"too many objects":    withTrailer(8, 1<<62),
+		"table past the end":  withTrailer(24, uint64(len(valid))),
+		"table offset wraps":  withTrailer(24, ^uint64(0)-3),
+		"top is not a dict":   withTrailer(16, 1),
+		"top out of range":    withTrailer(16, 100),
+		"huge dictionary":     buildPlist(0, plistMarker(0xD, 1<<63)),
+		"dictionary wraps":    buildPlist(0, plistMarker(0xD, 1<<63+1)),
+		"huge array":          buildPlist(0, plistMarker(0xA, 1<<62)),
+		"nested in itself":    buildPlist(0, plistDict(1, 0), plistASCII("k")),
+		"bad length marker":   buildPlist(0, []byte{0xDF, 0x50}),
+		"length past the end": buildPlist(0, []byte{0xDF, 0x13, 0x00}),
+	} {
+		t.Run(name, func(t *testing.T) {
+			// Self-references end at the depth limit with a nil value
+			if dict, err := parseBinaryPlist(data); err == nil && name != "nested in itself" {
+				t.Errorf("parsed %v, want an error", dict)
+			}
+		})
+	}
+}
+
+func TestParseBinaryPlistOverflowingStrings(t *testing.T) {
+	// Element counts whose byte size wraps around must not reach make()
+	for _, n := range []uint64{1 << 63, 1<<63 + 1, ^uint64(0), 1 << 62} {
+		data := buildPlist(0,
+			plistDict(1, 2, 3, 4),
+			plistASCII("utf16"),
+			plistASCII("ascii"),
+			plistMarker(0x6, n),
+			plistMarker(0x5, n),
+		)
+		dict, err := parseBinaryPlist(data)
+		if err != nil {
+			t.Fatalf("count %d: %v", n, err)
+		}
+		want := map[string]interface{}{"utf16": nil, "ascii": nil}
+		if !reflect.DeepEqual(dict, want) {
+			t.Errorf("count %d: got %#v, want %#v", n, dict, want)
+		}
+	}
+}
+
+func FuzzParseBinaryPlist(f *testing.F) {
+	f.Add(globalPreferences())
+	f.Add(buildPlist(0, plistDict(1, 2), plistASCII("k"), plistMarker(0x6, 1<<63)))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		parseBinaryPlist(data)
+	})
+}
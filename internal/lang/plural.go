@@ -0,0 +1,90 @@
+package lang
+
+// PluralCategory is a CLDR plural category
+type PluralCategory string
+
+// CLDR plural categories
+const (
+	Zero  PluralCategory = "zero"
+	One   PluralCategory = "one"
+	Two   PluralCategory = "two"
+	Few   PluralCategory = "few"
+	Many  PluralCategory = "many"
+	Other PluralCategory = "other"
+)
+
+// pluralRule holds the CLDR categories a language uses for integers, in
+// the order of its msgstr[n] forms, and the rule choosing among them
+type pluralRule struct {
+	categories []PluralCategory
+	choose     func(n uint64) PluralCategory
+}
+
+var (
+	oneOther = pluralRule{
+		categories: []PluralCategory{One, Other},
+		choose: func(n uint64) PluralCategory {
+			if n == 1 {
+				return One
+			}
+			return Other
+		},
+	}
+	otherOnly = pluralRule{
+		categories: []PluralCategory{Other},
+		choose:     func(uint64) PluralCategory { return Other },
+	}
+)
+
+// pluralRules maps each language to its rule. Spanish "many", used only
+// for multiples of a million, is folded into "other" like in its catalog.
+var pluralRules = map[Language]pluralRule{
+	EN: oneOther,
+	DE: oneOther,
+	ES: oneOther,
+	CN: otherOnly,
+	TW: otherOnly,
+	JA: otherOnly,
+	RU: {
+		categories: []PluralCategory{One, Few, Many},
+		choose: func(n uint64) PluralCategory {
+			switch {
+			case n%10 == 1 && n%100 != 11:
+				return One
+			case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+				return Few
+			default:
+				return Many
+			}
+		},
+	},
+}
+
+// Plural returns the plural category of n in lang
+func Plural(lang Language, n uint64) PluralCategory {
+	return ruleFor(lang).choose(n)
+}
+
+// PluralCategories returns the categories of lang in msgstr[n] order
+func PluralCategories(lang Language) []PluralCategory {
+	return append([]PluralCategory(nil), ruleFor(lang).categories...)
+}
+
+// pluralIndex returns the msgstr[n] index for n in lang
+func pluralIndex(lang Language, n uint64) int {
+	rule := ruleFor(lang)
+	category := rule.choose(n)
+	for i, c := range rule.categories {
+		if c == category {
+			return i
+		}
+	}
+	return len(rule.categories) - 1
+}
+
+func ruleFor(lang Language) pluralRule {
+	if rule, ok := pluralRules[lang]; ok {
+		return rule
+	}
+	return pluralRules[EN]
+}
@@ -0,0 +1,78 @@
+package lang
+
+import "testing"
+
+func TestPlural(t *testing.T) {
+	tests := []struct {
+		lang    Language
+		numbers []uint64
+		want    PluralCategory
+	}{
+		{RU, []uint64{1, 21, 31, 101, 1001}, One},
+		{RU, []uint64{2, 3, 4, 22, 24, 102, 1004}, Few},
+		{RU, []uint64{0, 5, 9, 10, 11, 12, 13, 14, 15, 20, 100, 111, 112, 114, 1000}, Many},
+		{EN, []uint64{1}, One},
+		{EN, []uint64{0, 2, 11, 21}, Other},
+		{DE, []uint64{1}, One},
+		{ES, []uint64{0, 2, 1000000}, Other},
+		{CN, []uint64{0, 1, 2, 5, 21}, Other},
+		{TW, []uint64{1, 2}, Other},
+		{JA, []uint64{1, 2}, Other},
+		{Language("fr"), []uint64{1}, One}, // Unknown languages use the English rule
+	}
+	for _, tt := range tests {
+		for _, n := range tt.numbers {
+			if got := Plural(tt.lang, n); got != tt.want {
+				t.Errorf("Plural(%s, %d) = %s, want %s", tt.lang, n, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestPluralCategoriesMatchRules(t *testing.T) {
+	for _, l := range Supported() {
+		categories := PluralCategories(l)
+		index := make(map[PluralCategory]int)
+		for i, c := range categories {
+			index[c] = i
+		}
+		// Every category a rule picks has a msgstr[n] form
+		for n := uint64(0); n < 1200; n++ {
+			c := Plural(l, n)
+			i, ok := index[c]
+			if !ok {
+				t.Fatalf("%s: Plural(%d) = %s, not in %v", l, n, c, categories)
+			}
+			if got := pluralIndex(l, n); got != i {
+				t.Fatalf("%s: pluralIndex(%d) = %d, want %d", l, n, got, i)
+			}
+		}
+	}
+}
+
+func TestTPlural(t *testing.T) {
+	defer SetLanguage(GetCurrentLanguage())
+
+	tests := []struct {
+		lang  Language
+		count interface{}
+		want  string
+	}{
+		{EN, 1, "Closed 1 Cursor process"},
+		{EN, 3, "Closed 3 Cursor processes"},
+		{EN, -1, "Closed -1 Cursor process"}, // Negative counts use their absolute value
+		{RU, 1, "Закрыт 1 процесс Cursor"},
+		{RU, 3, "Закрыто 3 процесса Cursor"},
+		{RU, 11, "Закрыто 11 процессов Cursor"},
+		{RU, uint8(21), "Закрыт 21 процесс Cursor"},
+		{CN, 1, "已关闭 1 个 Cursor 进程"},
+		{CN, 5, "已关闭 5 个 Cursor 进程"},
+		{EN, "many", "Closed many Cursor process"}, // Not a number: the singular is used
+	}
+	for _, tt := range tests {
+		SetLanguage(tt.lang)
+		if got := T("ProcessesClosed", Args{CountArg: tt.count}); got != tt.want {
+			t.Errorf("%s, %v: got %q, want %q", tt.lang, tt.count, got, tt.want)
+		}
+	}
+}
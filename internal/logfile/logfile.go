@@ -0,0 +1,159 @@
+// Package logfile writes the tool's log to a file that is rotated by size,
+// so that a log can be attached to bug reports without growing unbounded
+package logfile
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/yuaotian/go-cursor-help/internal/paths"
+)
+
+// FileName is the name of the log file inside the log directory
+const FileName = "cursor-id-modifier.log"
+
+// File is a log file that is rotated when it grows beyond a size. Rotated
+// files get the suffixes .1 (newest) to .N (oldest).
+type File struct {
+	path     string
+	username string
+	maxSize  int64 // Bytes; 0 disables rotation
+	maxFiles int   // Rotated files kept
+	file     *os.File
+	size     int64
+	mu       sync.Mutex
+}
+
+// Open opens path for appending, creating it and its directory owned by
+// username. The file is rotated first if it already exceeds maxSize bytes.
+func Open(path string, maxSize int64, maxFiles int, username string) (*File, error) {
+	if err := paths.EnsureDir(filepath.Dir(path), username); err != nil {
+		return nil, err
+	}
+	f := &File{path: path, username: username, maxSize: maxSize, maxFiles: maxFiles}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	if f.maxSize > 0 && f.size >= f.maxSize {
+		if err := f.rotate(); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Path returns the location of the log file
+func (f *File) Path() string {
+	return f.path
+}
+
+// Write appends p, rotating the file first if p would take it beyond the
+// maximum size
+func (f *File) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// Close closes the log file
+func (f *File) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+func (f *File) open() error {
+	_, statErr := os.Stat(f.path)
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	if os.IsNotExist(statErr) {
+		paths.ChownToUser(f.path, f.username)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+// rotate shifts the rotated files up by one, dropping the oldest, moves the
+// current file to .1 and starts a new one. The caller holds f.mu.
+func (f *File) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	f.file = nil
+
+	if f.maxFiles > 0 {
+		os.Remove(f.rotatedPath(f.maxFiles))
+		for i := f.maxFiles - 1; i >= 1; i-- {
+			os.Rename(f.rotatedPath(i), f.rotatedPath(i+1))
+		}
+		if err := os.Rename(f.path, f.rotatedPath(1)); err != nil {
+			return fmt.Errorf("failed to rotate log file: %w", err)
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+	return f.open()
+}
+
+func (f *File) rotatedPath(n int) string {
+	return fmt.Sprintf("%s.%d", f.path, n)
+}
+
+// Hook is a logrus hook that writes the entries up to a level to its own
+// writer and formatter. It lets one logger feed outputs with different
+// levels, such as a quiet terminal and a detailed log file.
+type Hook struct {
+	Writer    io.Writer
+	Level     logrus.Level // Most verbose level written
+	Formatter logrus.Formatter
+}
+
+// Levels implements logrus.Hook
+func (h *Hook) Levels() []logrus.Level {
+	var levels []logrus.Level
+	for _, level := range logrus.AllLevels {
+		if level <= h.Level {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// Fire implements logrus.Hook
+func (h *Hook) Fire(entry *logrus.Entry) error {
+	line, err := h.Formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = h.Writer.Write(line)
+	return err
+}
@@ -0,0 +1,175 @@
+package logfile
+
+import (
+	"errors"
+	"os"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func currentUsername(t *testing.T) string {
+	t.Helper()
+	u, err := user.Current()
+	if err != nil {
+		t.Fatalf("user.Current: %v", err)
+	}
+	return u.Username
+}
+
+// logFiles returns the contents of the files in dir by name
+func logFiles(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]string)
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[entry.Name()] = string(data)
+	}
+	return files
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxSize  int64
+		maxFiles int
+		writes   []string
+		want     map[string]string
+	}{
+		{
+			name:    "below the maximum size",
+			maxSize: 16, maxFiles: 2,
+			writes: []string{"line 1\n", "line 2\n"},
+			want:   map[string]string{FileName: "line 1\nline 2\n"},
+		},
+		{
+			name:    "rotated",
+			maxSize: 10, maxFiles: 2,
+			writes: []string{"line 1\n", "line 2\n", "line 3\n"},
+			want: map[string]string{
+				FileName:        "line 3\n",
+				FileName + ".1": "line 2\n",
+				FileName + ".2": "line 1\n",
+			},
+		},
+		{
+			name:    "oldest pruned",
+			maxSize: 10, maxFiles: 2,
+			writes: []string{"line 1\n", "line 2\n", "line 3\n", "line 4\n", "line 5\n"},
+			want: map[string]string{
+				FileName:        "line 5\n",
+				FileName + ".1": "line 4\n",
+				FileName + ".2": "line 3\n",
+			},
+		},
+		{
+			name:    "no rotated files kept",
+			maxSize: 10, maxFiles: 0,
+			writes: []string{"line 1\n", "line 2\n", "line 3\n"},
+			want:   map[string]string{FileName: "line 3\n"},
+		},
+		{
+			name:    "write larger than the maximum size",
+			maxSize: 4, maxFiles: 2,
+			writes: []string{"line 1\n", "line 2\n"},
+			want: map[string]string{
+				FileName:        "line 2\n",
+				FileName + ".1": "line 1\n",
+			},
+		},
+		{
+			name:    "rotation disabled",
+			maxSize: 0, maxFiles: 2,
+			writes: []string{"line 1\n", "line 2\n", "line 3\n"},
+			want:   map[string]string{FileName: "line 1\nline 2\nline 3\n"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			f, err := Open(filepath.Join(dir, FileName), tt.maxSize, tt.maxFiles, currentUsername(t))
+			if err != nil {
+				t.Fatalf("Open: %v", err)
+			}
+			for _, line := range tt.writes {
+				if n, err := f.Write([]byte(line)); err != nil || n != len(line) {
+					t.Fatalf("Write = %d, %v; want %d", n, err, len(line))
+				}
+			}
+			if err := f.Close(); err != nil {
+				t.Fatalf("Close: %v", err)
+			}
+
+			if got := logFiles(t, dir); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("files = %q\nwant %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenRotatesOversizedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, FileName)
+	if err := os.WriteFile(path, []byte("old log\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := Open(path, 8, 1, currentUsername(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := f.Write([]byte("new\n")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	f.Close()
+
+	want := map[string]string{FileName: "new\n", FileName + ".1": "old log\n"}
+	if got := logFiles(t, dir); !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %q\nwant %q", got, want)
+	}
+}
+
+func TestOpenAppends(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "logs", FileName)
+	for _, line := range []string{"first\n", "second\n"} {
+		f, err := Open(path, 0, 1, currentUsername(t))
+		if err != nil {
+			t.Fatalf("Open: %v", err)
+		}
+		f.Write([]byte(line))
+		f.Close()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first\nsecond\n" {
+		t.Errorf("log = %q, want both writes", data)
+	}
+}
+
+func TestWriteAfterClose(t *testing.T) {
+	f, err := Open(filepath.Join(t.TempDir(), FileName), 0, 1, currentUsername(t))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+	if _, err := f.Write([]byte("line\n")); !errors.Is(err, os.ErrClosed) {
+		t.Errorf("Write after Close = %v, want %v", err, os.ErrClosed)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package paths
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// FileOwner returns the uid owning path
+func FileOwner(path string) (string, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", false
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatUint(uint64(stat.Uid), 10), true
+}
@@ -0,0 +1,9 @@
+//go:build windows
+
+package paths
+
+// FileOwner is not implemented on Windows, where files in the user profile
+// are protected by ACLs rather than a single owner uid
+func FileOwner(path string) (string, bool) {
+	return "", false
+}
@@ -0,0 +1,136 @@
+// Package paths resolves per-user locations used by the tool itself
+package paths
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strconv"
+)
+
+// AppName is the directory name used for the tool's own files
+const AppName = "cursor-id-modifier"
+
+// HomeDir returns the home directory of the given user
+func HomeDir(username string) (string, error) {
+	if u, err := user.Lookup(username); err == nil && u.HomeDir != "" {
+		return u.HomeDir, nil
+	}
+	switch runtime.GOOS {
+	case "windows":
+		return os.Getenv("USERPROFILE"), nil
+	case "darwin":
+		return filepath.Join("/Users", username), nil
+	case "linux":
+		return filepath.Join("/home", username), nil
+	default:
+		return "", fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	}
+}
+
+// StateDir returns the per-user directory holding the tool's state,
+// such as the audit log
+func StateDir(username string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("LOCALAPPDATA"), AppName), nil
+	}
+
+	home, err := HomeDir(username)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "Application Support", AppName), nil
+	}
+	return filepath.Join(home, ".local", "state", AppName), nil
+}
+
+// LogDir returns the per-user directory holding the tool's log files
+func LogDir(username string) (string, error) {
+	if runtime.GOOS == "darwin" {
+		home, err := HomeDir(username)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Logs", AppName), nil
+	}
+
+	dir, err := StateDir(username)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "logs"), nil
+}
+
+// ConfigDir returns the per-user directory holding the tool's own
+// configuration file
+func ConfigDir(username string) (string, error) {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), AppName), nil
+	}
+
+	home, err := HomeDir(username)
+	if err != nil {
+		return "", err
+	}
+	if runtime.GOOS == "darwin" {
+		return filepath.Join(home, "Library", "Application Support", AppName), nil
+	}
+	return filepath.Join(home, ".config", AppName), nil
+}
+
+// SystemConfigDir returns the system-wide configuration directory
+func SystemConfigDir() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("ProgramData"), AppName)
+	}
+	return filepath.Join("/etc", AppName)
+}
+
+// EnsureDir creates dir and any missing parents, and hands the directories
+// it created to username when the tool runs with elevated privileges.
+// Existing directories, such as a shared parent of a custom path, keep
+// their owner.
+func EnsureDir(dir, username string) error {
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil || filepath.Dir(d) == d {
+			break
+		}
+		missing = append(missing, d)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	for _, d := range missing {
+		if err := ChownToUser(d, username); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ChownToUser gives ownership of path to username. It is a no-op on
+// Windows and when the tool is not running as root.
+func ChownToUser(path, username string) error {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		return nil
+	}
+
+	u, err := user.Lookup(username)
+	if err != nil {
+		return fmt.Errorf("failed to look up user %s: %w", username, err)
+	}
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return fmt.Errorf("invalid uid %q: %w", u.Uid, err)
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return fmt.Errorf("invalid gid %q: %w", u.Gid, err)
+	}
+	return os.Chown(path, uid, gid)
+}
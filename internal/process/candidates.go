@@ -0,0 +1,67 @@
+package process
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// Candidate is a process whose listing matched a Cursor pattern
+type Candidate struct {
+	PID        string `json:"pid"`
+	PPID       string `json:"ppid,omitempty"`
+	User       string `json:"user,omitempty"`
+	Executable string `json:"executable,omitempty"`
+	Pattern    string `json:"pattern"`
+	Excluded   string `json:"excluded,omitempty"` // Why it is not treated as Cursor
+}
+
+// Candidates returns every process matching a Cursor pattern, including
+// near misses that are excluded as the tool's own process or by the
+// denylist. It explains what getCursorProcesses considers to be Cursor.
+func (m *Manager) Candidates(ctx context.Context) ([]Candidate, error) {
+	cmd := m.getProcessListCommand(ctx)
+	if cmd == nil {
+		return nil, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+	}
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute command: %w", err)
+	}
+
+	table, err := processTable(ctx)
+	if err != nil {
+		m.log.Debugf("Failed to read process table: %v", err)
+	}
+	owners, err := processOwners(ctx)
+	if err != nil {
+		m.log.Debugf("Failed to read process owners: %v", err)
+	}
+
+	var candidates []Candidate
+	for _, line := range strings.Split(string(output), "\n") {
+		match := m.matchLine(line)
+		if match.PID == "" {
+			continue
+		}
+
+		info := table[match.PID]
+		c := Candidate{
+			PID:        match.PID,
+			PPID:       info.PPID,
+			User:       info.User,
+			Executable: info.Name,
+			Pattern:    match.Pattern,
+			Excluded:   match.Excluded,
+		}
+		if owner, ok := owners[match.PID]; ok {
+			c.User = owner
+		}
+		if p, err := Describe(match.PID); err == nil && p.Executable != "" {
+			c.Executable = p.Executable
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, nil
+}
@@ -0,0 +1,46 @@
+//go:build darwin
+
+package process
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Describe returns the executable, arguments and working directory of a
+// process using ps and lsof. Arguments containing spaces cannot be told
+// apart from separate arguments.
+func Describe(pid string) (*Process, error) {
+	comm, err := exec.Command("ps", "-o", "comm=", "-p", pid).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get executable: %w", err)
+	}
+	command, err := exec.Command("ps", "-o", "command=", "-p", pid).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get command line: %w", err)
+	}
+
+	exe := strings.TrimSpace(string(comm))
+	p := &Process{PID: pid, Executable: exe}
+	if rest := strings.TrimPrefix(strings.TrimSpace(string(command)), exe); rest != "" {
+		p.Args = strings.Fields(rest)
+	}
+
+	// lsof prints the working directory as a field line starting with 'n'
+	if out, err := exec.Command("lsof", "-a", "-p", pid, "-d", "cwd", "-Fn").Output(); err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			if strings.HasPrefix(line, "n") {
+				p.Dir = line[1:]
+				break
+			}
+		}
+	}
+	return p, nil
+}
+
+// isZombie reports whether pid has exited but not been reaped by its parent
+func isZombie(pid string) bool {
+	out, err := exec.Command("ps", "-o", "stat=", "-p", pid).Output()
+	return err == nil && strings.HasPrefix(strings.TrimSpace(string(out)), "Z")
+}
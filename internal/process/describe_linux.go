@@ -0,0 +1,59 @@
+//go:build linux
+
+package process
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Describe returns the executable, arguments, working directory and
+// environment of a process from /proc
+func Describe(pid string) (*Process, error) {
+	procDir := filepath.Join("/proc", pid)
+
+	cmdline, err := os.ReadFile(filepath.Join(procDir, "cmdline"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read command line: %w", err)
+	}
+	args := splitNul(cmdline)
+	if len(args) == 0 {
+		return nil, fmt.Errorf("process %s has no command line", pid)
+	}
+
+	p := &Process{PID: pid, Executable: args[0], Args: args[1:]}
+	if exe, err := os.Readlink(filepath.Join(procDir, "exe")); err == nil {
+		p.Executable = exe
+	}
+	if dir, err := os.Readlink(filepath.Join(procDir, "cwd")); err == nil {
+		p.Dir = dir
+	}
+	if environ, err := os.ReadFile(filepath.Join(procDir, "environ")); err == nil {
+		p.Env = splitNul(environ)
+	}
+	return p, nil
+}
+
+func splitNul(data []byte) []string {
+	var fields []string
+	for _, field := range bytes.Split(bytes.TrimRight(data, "\x00"), []byte{0}) {
+		if len(field) > 0 {
+			fields = append(fields, string(field))
+		}
+	}
+	return fields
+}
+
+// isZombie reports whether pid has exited but not been reaped by its parent
+func isZombie(pid string) bool {
+	stat, err := os.ReadFile(filepath.Join("/proc", pid, "stat"))
+	if err != nil {
+		return false
+	}
+	// The state follows the command name, which is in parentheses and may
+	// itself contain spaces or parentheses
+	i := bytes.LastIndexByte(stat, ')')
+	return i >= 0 && i+2 < len(stat) && stat[i+2] == 'Z'
+}
@@ -0,0 +1,18 @@
+//go:build !linux && !darwin && !windows
+
+package process
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Describe is not supported on this operating system
+func Describe(pid string) (*Process, error) {
+	return nil, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+}
+
+// isZombie cannot tell zombie processes apart on this operating system
+func isZombie(pid string) bool {
+	return false
+}
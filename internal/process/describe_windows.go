@@ -0,0 +1,44 @@
+//go:build windows
+
+package process
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+)
+
+// Describe returns the executable and arguments of a process using CIM.
+// Windows does not expose another process's working directory, so the
+// executable's directory is used instead.
+func Describe(pid string) (*Process, error) {
+	script := fmt.Sprintf("Get-CimInstance Win32_Process -Filter 'ProcessId=%s' | "+
+		"Select-Object ExecutablePath,CommandLine | ConvertTo-Json -Compress", pid)
+	out, err := exec.Command("powershell", "-NoProfile", "-Command", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query process: %w", err)
+	}
+
+	var info struct {
+		ExecutablePath string
+		CommandLine    string
+	}
+	if err := json.Unmarshal(out, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse process information: %w", err)
+	}
+	if info.ExecutablePath == "" {
+		return nil, fmt.Errorf("process %s not found", pid)
+	}
+
+	p := &Process{PID: pid, Executable: info.ExecutablePath, Dir: filepath.Dir(info.ExecutablePath)}
+	if args := splitCommandLine(info.CommandLine); len(args) > 1 {
+		p.Args = args[1:]
+	}
+	return p, nil
+}
+
+// isZombie is always false on Windows, which has no zombie processes
+func isZombie(pid string) bool {
+	return false
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// signalKill sends SIGKILL to pid and returns the signal name
+func signalKill(ctx context.Context, pid string) (string, error) {
+	const signal = "SIGKILL"
+	if err := ctx.Err(); err != nil {
+		return signal, err
+	}
+	n, err := strconv.Atoi(pid)
+	if err != nil {
+		return signal, fmt.Errorf("invalid pid %q", pid)
+	}
+
+	err = syscall.Kill(n, syscall.SIGKILL)
+	switch {
+	case err == nil:
+		return signal, nil
+	case errors.Is(err, syscall.ESRCH):
+		return signal, ErrNotFound
+	case errors.Is(err, syscall.EPERM):
+		return signal, fmt.Errorf("failed to kill process %s: %w", pid, os.ErrPermission)
+	default:
+		return signal, fmt.Errorf("failed to kill process %s: %w", pid, err)
+	}
+}
+
+// signalClose is not used outside Windows, where Cursor is killed directly
+func signalClose(ctx context.Context, pid string) (string, error) {
+	return "", nil
+}
@@ -0,0 +1,44 @@
+//go:build windows
+
+package process
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// taskkillNotFound is the exit code of taskkill for an unknown PID
+const taskkillNotFound = 128
+
+// signalClose asks pid to close its windows, like clicking the close button
+func signalClose(ctx context.Context, pid string) (string, error) {
+	return "WM_CLOSE", taskkill(ctx, pid)
+}
+
+// signalKill terminates pid forcefully
+func signalKill(ctx context.Context, pid string) (string, error) {
+	return "TerminateProcess", taskkill(ctx, pid, "/F")
+}
+
+// taskkill runs taskkill for pid and classifies its failure
+func taskkill(ctx context.Context, pid string, flags ...string) error {
+	args := append(flags, "/PID", pid)
+	out, err := exec.CommandContext(ctx, "taskkill", args...).CombinedOutput()
+	if err == nil {
+		return nil
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == taskkillNotFound:
+		return ErrNotFound
+	case strings.Contains(strings.ToLower(string(out)), "access is denied"):
+		return fmt.Errorf("failed to kill process %s: %w", pid, os.ErrPermission)
+	default:
+		return fmt.Errorf("failed to kill process %s: %w: %s", pid, err, strings.TrimSpace(string(out)))
+	}
+}
@@ -0,0 +1,99 @@
+//go:build !windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// Launch starts p again as username, detached from the tool in its own
+// session, in the working directory p was started from. On macOS apps
+// inside a bundle are started through open so that they attach to the
+// user's GUI session.
+func Launch(p Process, username string) error {
+	cmd := exec.Command(p.Executable, p.Args...)
+	if runtime.GOOS == "darwin" {
+		if i := strings.Index(p.Executable, ".app/Contents/MacOS/"); i >= 0 {
+			args := []string{"-a", p.Executable[:i+len(".app")]}
+			if len(p.Args) > 0 {
+				args = append(append(args, "--args"), p.Args...)
+			}
+			cmd = exec.Command("open", args...)
+		}
+	}
+	if dir := p.Dir; dir != "" {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			cmd.Dir = dir
+		}
+	}
+
+	attr := &syscall.SysProcAttr{Setsid: true}
+	env := p.Env
+	if os.Geteuid() == 0 && username != "" {
+		u, err := user.Lookup(username)
+		if err != nil {
+			return fmt.Errorf("failed to look up user %s: %w", username, err)
+		}
+		if u.Uid != "0" {
+			cred, err := credential(u)
+			if err != nil {
+				return err
+			}
+			attr.Credential = cred
+		}
+		if len(env) == 0 {
+			env = userEnv(u)
+		}
+	}
+	cmd.SysProcAttr = attr
+	cmd.Env = env
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", p.Executable, err)
+	}
+	return cmd.Process.Release()
+}
+
+// credential returns the uid, gid and supplementary groups of u
+func credential(u *user.User) (*syscall.Credential, error) {
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid uid %q: %w", u.Uid, err)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid gid %q: %w", u.Gid, err)
+	}
+
+	cred := &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	if groupIDs, err := u.GroupIds(); err == nil {
+		for _, id := range groupIDs {
+			if g, err := strconv.ParseUint(id, 10, 32); err == nil {
+				cred.Groups = append(cred.Groups, uint32(g))
+			}
+		}
+	}
+	return cred, nil
+}
+
+// userEnv returns the current environment with the identity variables of
+// u and without those set by sudo
+func userEnv(u *user.User) []string {
+	var env []string
+	for _, kv := range os.Environ() {
+		name := strings.SplitN(kv, "=", 2)[0]
+		switch {
+		case strings.HasPrefix(name, "SUDO_"), name == "HOME", name == "USER", name == "LOGNAME":
+			continue
+		}
+		env = append(env, kv)
+	}
+	return append(env, "HOME="+u.HomeDir, "USER="+u.Username, "LOGNAME="+u.Username)
+}
@@ -0,0 +1,85 @@
+//go:build windows
+
+package process
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+const (
+	detachedProcess       = 0x00000008
+	createNewProcessGroup = 0x00000200
+)
+
+// Launch starts p again, detached from the tool. It is always started
+// through Explorer, which runs it unelevated as the signed-in user even when
+// the tool itself is elevated. Explorer passes no arguments, so when p had
+// any, Explorer runs a batch file that starts p with them and deletes itself.
+func Launch(p Process, username string) error {
+	target := p.Executable
+	if len(p.Args) > 0 {
+		script, err := writeLaunchScript(p)
+		if err != nil {
+			return err
+		}
+		target = script
+	}
+
+	cmd := exec.Command("explorer.exe", target)
+	cmd.Dir = p.Dir
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: detachedProcess | createNewProcessGroup}
+
+	if err := cmd.Start(); err != nil {
+		if target != p.Executable {
+			os.Remove(target)
+		}
+		return fmt.Errorf("failed to start %s: %w", p.Executable, err)
+	}
+	return cmd.Process.Release()
+}
+
+// writeLaunchScript writes a batch file that starts p with its arguments in
+// its working directory, then deletes itself
+func writeLaunchScript(p Process) (string, error) {
+	var b strings.Builder
+	b.WriteString("@echo off\r\nchcp 65001 >nul\r\n")
+	if p.Dir != "" {
+		dir, err := batchQuote(p.Dir)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "cd /d %s\r\n", dir)
+	}
+	exe, err := batchQuote(p.Executable)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(`start "" ` + exe)
+	for _, arg := range p.Args {
+		quoted, err := batchQuote(arg)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(" " + quoted)
+	}
+	b.WriteString("\r\n(goto) 2>nul & del \"%~f0\"\r\n")
+
+	file, err := os.CreateTemp("", "cursor-launch-*.cmd")
+	if err != nil {
+		return "", fmt.Errorf("failed to create launch script: %w", err)
+	}
+	if _, err := file.WriteString(b.String()); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write launch script: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return "", fmt.Errorf("failed to write launch script: %w", err)
+	}
+	return file.Name(), nil
+}
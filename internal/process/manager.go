@@ -1,9 +1,13 @@
 package process
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 
@@ -15,6 +19,7 @@ type Config struct {
 	MaxAttempts     int           // Maximum number of attempts to kill processes
 	RetryDelay      time.Duration // Delay between retry attempts
 	ProcessPatterns []string      // Process names to look for
+	ExcludePatterns []string      // Processes never treated as Cursor, in the same syntax
 }
 
 // DefaultConfig returns the default configuration
@@ -38,6 +43,7 @@ func DefaultConfig() *Config {
 type Manager struct {
 	config *Config
 	log    *logrus.Logger
+	closed []Process
 }
 
 // NewManager creates a new process manager with optional config and logger
@@ -55,8 +61,8 @@ func NewManager(config *Config, log *logrus.Logger) *Manager {
 }
 
 // IsCursorRunning checks if any Cursor process is currently running
-func (m *Manager) IsCursorRunning() bool {
-	processes, err := m.getCursorProcesses()
+func (m *Manager) IsCursorRunning(ctx context.Context) bool {
+	processes, err := m.getCursorProcesses(ctx)
 	if err != nil {
 		m.log.Warn("Failed to get Cursor processes:", err)
 		return false
@@ -64,45 +70,218 @@ func (m *Manager) IsCursorRunning() bool {
 	return len(processes) > 0
 }
 
-// KillCursorProcesses attempts to kill all running Cursor processes
-func (m *Manager) KillCursorProcesses() error {
+// CursorProcesses returns the PIDs of running Cursor processes
+func (m *Manager) CursorProcesses(ctx context.Context) ([]string, error) {
+	return m.getCursorProcesses(ctx)
+}
+
+// KillCursorProcesses attempts to kill all running Cursor processes and
+// reports what happened to each of them. The error wraps ErrStillRunning
+// when processes survive every attempt; the result is returned with it.
+// It stops early with the context's error when ctx is cancelled.
+func (m *Manager) KillCursorProcesses(ctx context.Context) (*KillResult, error) {
+	result := &KillResult{}
 	for attempt := 1; attempt <= m.config.MaxAttempts; attempt++ {
-		processes, err := m.getCursorProcesses()
+		processes, err := m.getCursorProcesses(ctx)
 		if err != nil {
-			return fmt.Errorf("failed to get processes: %w", err)
+			return result, fmt.Errorf("failed to get processes: %w", err)
 		}
 
 		if len(processes) == 0 {
-			return nil
+			break
+		}
+		result.Attempts = attempt
+
+		// Remember how the processes were started so they can be relaunched
+		if attempt == 1 {
+			m.recordClosed(processes)
 		}
 
-		// Try graceful shutdown first on Windows
+		tree := m.processTree(ctx, processes)
+		result.recordTree(tree)
+		if attempt == 1 {
+			result.Tree = tree
+			m.log.Debugf("Cursor process tree:\n%s", tree)
+		}
+
+		// Close the main processes first; Electron children exit with them
+		// instead of being respawned or orphaned
 		if runtime.GOOS == "windows" {
-			for _, pid := range processes {
-				exec.Command("taskkill", "/PID", pid).Run()
-				time.Sleep(500 * time.Millisecond)
+			// Try graceful shutdown first on Windows
+			for _, pid := range tree.RootPIDs() {
+				signal, err := signalClose(ctx, pid)
+				result.recordSignal(pid, signal, err)
+				if err := sleep(ctx, 500*time.Millisecond); err != nil {
+					return result, err
+				}
 			}
 		}
+		for _, pid := range tree.RootPIDs() {
+			m.kill(ctx, result, pid)
+		}
+		if err := sleep(ctx, sweepDelay); err != nil {
+			return result, err
+		}
+
+		// Sweep descendants that survived their parent, parents first
+		remaining, _ := m.getCursorProcesses(ctx)
+		for _, pid := range sweepOrder(tree, remaining) {
+			m.kill(ctx, result, pid)
+		}
+
+		if err := sleep(ctx, m.config.RetryDelay); err != nil {
+			return result, err
+		}
+
+		if processes, _ := m.getCursorProcesses(ctx); len(processes) == 0 {
+			break
+		}
+	}
+
+	running, err := m.getCursorProcesses(ctx)
+	if err != nil {
+		return result, fmt.Errorf("failed to get processes: %w", err)
+	}
+	result.classify(running)
+	return result, result.err()
+}
+
+// sweepDelay is how long children get to exit after their main process
+const sweepDelay = 300 * time.Millisecond
+
+// kill sends the forceful signal to pid and records it in result
+func (m *Manager) kill(ctx context.Context, result *KillResult, pid string) {
+	signal, err := signalKill(ctx, pid)
+	if err != nil {
+		m.log.Debugf("Failed to kill process %s: %v", pid, err)
+	}
+	result.recordSignal(pid, signal, err)
+}
+
+// processTree arranges pids by parent. Without a process table every
+// process is treated as a root.
+func (m *Manager) processTree(ctx context.Context, pids []string) *Tree {
+	table, err := processTable(ctx)
+	if err != nil {
+		m.log.Debugf("Failed to read process table: %v", err)
+	}
+	return buildTree(pids, table)
+}
+
+// CursorTree returns the running Cursor processes arranged by parent
+func (m *Manager) CursorTree(ctx context.Context) (*Tree, error) {
+	pids, err := m.getCursorProcesses(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.processTree(ctx, pids), nil
+}
 
-		// Force kill remaining processes
-		remainingProcesses, _ := m.getCursorProcesses()
-		for _, pid := range remainingProcesses {
-			m.killProcess(pid)
+// sweepOrder returns the remaining PIDs, those in tree parents first
+func sweepOrder(tree *Tree, remaining []string) []string {
+	alive := make(map[string]bool, len(remaining))
+	for _, pid := range remaining {
+		alive[pid] = true
+	}
+
+	var order []string
+	for _, pid := range tree.Order() {
+		if alive[pid] {
+			order = append(order, pid)
+			delete(alive, pid)
+		}
+	}
+	for _, pid := range remaining {
+		if alive[pid] {
+			order = append(order, pid)
 		}
+	}
+	return order
+}
+
+// ErrWaitTimeout is returned by WaitForExit when Cursor is still running
+// after the timeout
+var ErrWaitTimeout = errors.New("timed out waiting for Cursor to exit")
 
-		time.Sleep(m.config.RetryDelay)
+// waitPollInterval is how often WaitForExit checks for running processes
+const waitPollInterval = time.Second
 
-		if processes, _ := m.getCursorProcesses(); len(processes) == 0 {
+// WaitForExit polls until no Cursor process is running, without signalling
+// any. update, if not nil, is called with the PIDs still running whenever
+// the set changes. A timeout of zero waits indefinitely; cancelling ctx
+// stops waiting with the context's error.
+func (m *Manager) WaitForExit(ctx context.Context, timeout time.Duration, update func(pids []string)) error {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+
+	var last []string
+	for first := true; ; first = false {
+		processes, err := m.getCursorProcesses(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get processes: %w", err)
+		}
+		if len(processes) == 0 {
 			return nil
 		}
+
+		// Remember how the processes were started so they can be relaunched
+		if first {
+			m.recordClosed(processes)
+		}
+		if update != nil && (first || strings.Join(processes, ",") != strings.Join(last, ",")) {
+			update(processes)
+		}
+		last = processes
+
+		if !deadline.IsZero() && time.Now().After(deadline) {
+			return ErrWaitTimeout
+		}
+		if err := sleep(ctx, waitPollInterval); err != nil {
+			return err
+		}
+	}
+}
+
+// sleep waits for d, returning early with the context's error when ctx is
+// cancelled
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
+}
 
-	return nil
+// Closed returns the processes found by KillCursorProcesses or
+// WaitForExit, as recorded before they exited
+func (m *Manager) Closed() []Process {
+	return m.closed
+}
+
+// recordClosed describes the given processes before they are closed
+func (m *Manager) recordClosed(pids []string) {
+	m.closed = nil
+	for _, pid := range pids {
+		p, err := Describe(pid)
+		if err != nil {
+			m.log.Debugf("Failed to describe process %s: %v", pid, err)
+			continue
+		}
+		m.closed = append(m.closed, *p)
+	}
 }
 
 // getCursorProcesses returns PIDs of running Cursor processes
-func (m *Manager) getCursorProcesses() ([]string, error) {
-	cmd := m.getProcessListCommand()
+func (m *Manager) getCursorProcesses(ctx context.Context) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cmd := m.getProcessListCommand(ctx)
 	if cmd == nil {
 		return nil, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
 	}
@@ -116,34 +295,62 @@ func (m *Manager) getCursorProcesses() ([]string, error) {
 }
 
 // getProcessListCommand returns the appropriate command to list processes based on OS
-func (m *Manager) getProcessListCommand() *exec.Cmd {
+func (m *Manager) getProcessListCommand(ctx context.Context) *exec.Cmd {
 	switch runtime.GOOS {
 	case "windows":
-		return exec.Command("tasklist", "/FO", "CSV", "/NH")
+		return exec.CommandContext(ctx, "tasklist", "/FO", "CSV", "/NH")
 	case "darwin":
-		return exec.Command("ps", "-ax")
+		return exec.CommandContext(ctx, "ps", "-ax")
 	case "linux":
-		return exec.Command("ps", "-A")
+		return exec.CommandContext(ctx, "ps", "-A")
 	default:
 		return nil
 	}
 }
 
+// ownPID is this process's PID. Linux truncates command names to 15
+// characters, so name-based self-detection alone is not reliable.
+var ownPID = strconv.Itoa(os.Getpid())
+
 // parseProcessList extracts Cursor process PIDs from process list output
 func (m *Manager) parseProcessList(output string) []string {
 	var processes []string
 	for _, line := range strings.Split(output, "\n") {
-		lowerLine := strings.ToLower(line)
-
-		if m.isOwnProcess(lowerLine) {
-			continue
+		if match := m.matchLine(line); match.PID != "" && match.Excluded == "" {
+			processes = append(processes, match.PID)
 		}
+	}
+	return processes
+}
+
+// lineMatch is how a process list line was classified
+type lineMatch struct {
+	PID      string
+	Pattern  string // Pattern that matched; empty when none did
+	Excluded string // Why a matching process is not treated as Cursor
+}
 
-		if pid := m.findCursorProcess(line, lowerLine); pid != "" {
-			processes = append(processes, pid)
+// matchLine classifies a process list line against the configured patterns
+func (m *Manager) matchLine(line string) lineMatch {
+	lowerLine := strings.ToLower(line)
+	pattern := m.findPattern(lowerLine, m.config.ProcessPatterns)
+	if pattern == "" {
+		return lineMatch{}
+	}
+	match := lineMatch{PID: m.extractPID(line), Pattern: pattern}
+	if match.PID == "" {
+		return lineMatch{}
+	}
+
+	switch {
+	case m.isOwnProcess(lowerLine), match.PID == ownPID:
+		match.Excluded = "own process"
+	default:
+		if deny := m.findPattern(lowerLine, m.config.ExcludePatterns); deny != "" {
+			match.Excluded = "denylist " + deny
 		}
 	}
-	return processes
+	return match
 }
 
 // isOwnProcess checks if the process belongs to this application
@@ -152,11 +359,12 @@ func (m *Manager) isOwnProcess(line string) bool {
 		strings.Contains(line, "cursor-helper")
 }
 
-// findCursorProcess checks if a process line matches Cursor patterns and returns its PID
-func (m *Manager) findCursorProcess(line, lowerLine string) string {
-	for _, pattern := range m.config.ProcessPatterns {
+// findPattern returns the first of patterns that matches a lowercased
+// process line, or an empty string
+func (m *Manager) findPattern(lowerLine string, patterns []string) string {
+	for _, pattern := range patterns {
 		if m.matchPattern(lowerLine, strings.ToLower(pattern)) {
-			return m.extractPID(line)
+			return pattern
 		}
 	}
 	return ""
@@ -193,24 +401,3 @@ func (m *Manager) extractPID(line string) string {
 	}
 	return ""
 }
-
-// killProcess forcefully terminates a process by PID
-func (m *Manager) killProcess(pid string) error {
-	cmd := m.getKillCommand(pid)
-	if cmd == nil {
-		return fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
-	}
-	return cmd.Run()
-}
-
-// getKillCommand returns the appropriate command to kill a process based on OS
-func (m *Manager) getKillCommand(pid string) *exec.Cmd {
-	switch runtime.GOOS {
-	case "windows":
-		return exec.Command("taskkill", "/F", "/PID", pid)
-	case "darwin", "linux":
-		return exec.Command("kill", "-9", pid)
-	default:
-		return nil
-	}
-}
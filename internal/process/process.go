@@ -0,0 +1,87 @@
+package process
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Process describes how a running process was started
+type Process struct {
+	PID        string   `json:"pid"`
+	Executable string   `json:"executable"`
+	Args       []string `json:"args"` // Arguments after the executable
+	Dir        string   `json:"dir,omitempty"`
+	Env        []string `json:"-"`
+}
+
+// LaunchTargets returns the distinct top-level application processes among
+// processes, leaving out Electron helpers such as renderer and GPU processes
+// that the application starts itself
+func LaunchTargets(processes []Process) []Process {
+	var targets []Process
+	seen := make(map[string]bool)
+	for _, p := range processes {
+		if p.Executable == "" || isHelperProcess(p) {
+			continue
+		}
+		key := p.Executable + "\x00" + strings.Join(p.Args, "\x00")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		targets = append(targets, p)
+	}
+	return targets
+}
+
+// isHelperProcess reports whether p is a child process spawned by Electron
+func isHelperProcess(p Process) bool {
+	for _, arg := range p.Args {
+		if strings.HasPrefix(arg, "--type=") {
+			return true
+		}
+	}
+	return strings.Contains(strings.ToLower(filepath.Base(p.Executable)), "helper")
+}
+
+// splitCommandLine splits a Windows-style command line into arguments,
+// honouring double quotes
+func splitCommandLine(line string) []string {
+	var args []string
+	var current strings.Builder
+	inQuotes, hasArg := false, false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case (r == ' ' || r == '\t') && !inQuotes:
+			if hasArg {
+				args = append(args, current.String())
+				current.Reset()
+				hasArg = false
+			}
+		default:
+			current.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, current.String())
+	}
+	return args
+}
+
+// batchQuote quotes s as one argument on a batch file command line. Inside
+// quotes cmd.exe takes &, | and the like literally; % is doubled so it is
+// not expanded. Quotes and line breaks cannot be passed safely.
+func batchQuote(s string) (string, error) {
+	if strings.ContainsAny(s, "\"\r\n") {
+		return "", fmt.Errorf("cannot pass %q through a launch script", s)
+	}
+	// Backslashes before the closing quote would escape it
+	trailing := len(s) - len(strings.TrimRight(s, `\`))
+	s = strings.ReplaceAll(s, "%", "%%") + strings.Repeat(`\`, trailing)
+	return `"` + s + `"`, nil
+}
@@ -0,0 +1,60 @@
+package process
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"", nil},
+		{`C:\Cursor\Cursor.exe`, []string{`C:\Cursor\Cursor.exe`}},
+		{`"C:\Program Files\Cursor\Cursor.exe" --flag "a b"`, []string{`C:\Program Files\Cursor\Cursor.exe`, "--flag", "a b"}},
+		{"a  b\t c ", []string{"a", "b", "c"}},
+		{`a "" b`, []string{"a", "", "b"}},
+		{`--dir="C:\My Projects"x`, []string{`--dir=C:\My Projectsx`}},
+		{`%APPDATA%\x ^& a&b`, []string{`%APPDATA%\x`, "^&", "a&b"}},
+		{`"100% & ^done"`, []string{"100% & ^done"}},
+	}
+	for _, tt := range tests {
+		if got := splitCommandLine(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitCommandLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestBatchQuote(t *testing.T) {
+	tests := []struct {
+		arg  string
+		want string
+	}{
+		{"", `""`},
+		{"--flag", `"--flag"`},
+		{`C:\Program Files\Cursor\Cursor.exe`, `"C:\Program Files\Cursor\Cursor.exe"`},
+		{"100%", `"100%%"`},
+		{`%APPDATA%\Cursor`, `"%%APPDATA%%\Cursor"`},
+		{"a & b", `"a & b"`},
+		{"^caret | pipe > file", `"^caret | pipe > file"`},
+		{`C:\My Projects\`, `"C:\My Projects\\"`},
+		{`C:\dir\\`, `"C:\dir\\\\"`},
+	}
+	for _, tt := range tests {
+		got, err := batchQuote(tt.arg)
+		if err != nil {
+			t.Errorf("batchQuote(%q): %v", tt.arg, err)
+		} else if got != tt.want {
+			t.Errorf("batchQuote(%q) = %s, want %s", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestBatchQuoteRejects(t *testing.T) {
+	for _, arg := range []string{`say "hi"`, "line\nbreak", "line\rbreak"} {
+		if got, err := batchQuote(arg); err == nil {
+			t.Errorf("batchQuote(%q) = %s, want an error", arg, got)
+		}
+	}
+}
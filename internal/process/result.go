@@ -0,0 +1,134 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ErrNotFound is returned when a process exited before it could be signalled
+var ErrNotFound = errors.New("process not found")
+
+// ErrStillRunning is returned by KillCursorProcesses when Cursor processes
+// survive every attempt
+var ErrStillRunning = errors.New("cursor processes still running")
+
+// Outcome is what became of a process that KillCursorProcesses signalled
+type Outcome string
+
+const (
+	OutcomeExited           Outcome = "exited"
+	OutcomeNotFound         Outcome = "not_found" // Gone before it was signalled
+	OutcomeZombie           Outcome = "zombie"    // Dead but not yet reaped by its parent
+	OutcomePermissionDenied Outcome = "permission_denied"
+	OutcomeStillRunning     Outcome = "still_running"
+)
+
+// Survived reports whether the process is still running
+func (o Outcome) Survived() bool {
+	return o == OutcomePermissionDenied || o == OutcomeStillRunning
+}
+
+// KillStatus describes one process handled by KillCursorProcesses
+type KillStatus struct {
+	PID     string  `json:"pid"`
+	PPID    string  `json:"ppid,omitempty"`
+	Signal  string  `json:"signal,omitempty"` // Last signal sent, empty if none was needed
+	Outcome Outcome `json:"outcome"`
+	Error   string  `json:"error,omitempty"`
+	Err     error   `json:"-"` // Error of the last signal, for errors.Is
+}
+
+// KillResult is the outcome of KillCursorProcesses
+type KillResult struct {
+	Attempts  int          `json:"attempts"`
+	Tree      *Tree        `json:"tree,omitempty"` // Processes found by the first attempt
+	Processes []KillStatus `json:"processes"`
+}
+
+// Survivors returns the processes that are still running
+func (r *KillResult) Survivors() []KillStatus {
+	var survivors []KillStatus
+	for _, p := range r.Processes {
+		if p.Outcome.Survived() {
+			survivors = append(survivors, p)
+		}
+	}
+	return survivors
+}
+
+// status returns the entry for pid, adding it if needed
+func (r *KillResult) status(pid string) *KillStatus {
+	for i := range r.Processes {
+		if r.Processes[i].PID == pid {
+			return &r.Processes[i]
+		}
+	}
+	r.Processes = append(r.Processes, KillStatus{PID: pid})
+	return &r.Processes[len(r.Processes)-1]
+}
+
+// recordTree adds every process of tree that is not yet in the result
+func (r *KillResult) recordTree(tree *Tree) {
+	var add func(nodes []*Node)
+	add = func(nodes []*Node) {
+		for _, node := range nodes {
+			r.status(node.PID).PPID = node.PPID
+			add(node.Children)
+		}
+	}
+	add(tree.Roots)
+}
+
+// recordSignal stores the signal sent to pid and its error. A process
+// that is gone after an earlier signal keeps that signal.
+func (r *KillResult) recordSignal(pid, signal string, err error) {
+	s := r.status(pid)
+	if errors.Is(err, ErrNotFound) && s.Signal != "" {
+		return
+	}
+	s.Signal, s.Err, s.Error = signal, err, ""
+	if err != nil {
+		s.Error = err.Error()
+	}
+}
+
+// classify sets the outcome of every process given the PIDs still listed.
+// Listed processes that were never signalled, such as ones respawned after
+// the last attempt, are added so that they count as survivors.
+func (r *KillResult) classify(running []string) {
+	listed := make(map[string]bool, len(running))
+	for _, pid := range running {
+		listed[pid] = true
+		r.status(pid)
+	}
+
+	for i := range r.Processes {
+		s := &r.Processes[i]
+		switch {
+		case !listed[s.PID] && errors.Is(s.Err, ErrNotFound):
+			s.Outcome = OutcomeNotFound
+		case !listed[s.PID]:
+			s.Outcome = OutcomeExited
+		case isZombie(s.PID):
+			s.Outcome = OutcomeZombie
+		case errors.Is(s.Err, os.ErrPermission):
+			s.Outcome = OutcomePermissionDenied
+		default:
+			s.Outcome = OutcomeStillRunning
+		}
+	}
+}
+
+// err returns ErrStillRunning, with the surviving PIDs, if any process survived
+func (r *KillResult) err() error {
+	survivors := r.Survivors()
+	if len(survivors) == 0 {
+		return nil
+	}
+	pids := make([]string, len(survivors))
+	for i, s := range survivors {
+		pids[i] = s.PID
+	}
+	return fmt.Errorf("%w after %d attempts: %v", ErrStillRunning, r.Attempts, pids)
+}
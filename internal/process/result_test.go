@@ -0,0 +1,58 @@
+package process
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestClassify(t *testing.T) {
+	// PIDs far above any real pid_max, so no process is a zombie
+	result := &KillResult{Attempts: 3}
+	result.recordSignal("900001", "SIGKILL", nil)
+	result.recordSignal("900002", "SIGKILL", fmt.Errorf("kill: %w", os.ErrPermission))
+	result.recordSignal("900003", "SIGKILL", nil)
+	result.recordSignal("900004", "", ErrNotFound)
+
+	result.classify([]string{"900002", "900003", "900005"})
+
+	want := map[string]Outcome{
+		"900001": OutcomeExited,
+		"900002": OutcomePermissionDenied,
+		"900003": OutcomeStillRunning,
+		"900004": OutcomeNotFound,
+		"900005": OutcomeStillRunning, // Respawned, never signalled
+	}
+	if len(result.Processes) != len(want) {
+		t.Fatalf("got %d processes, want %d: %+v", len(result.Processes), len(want), result.Processes)
+	}
+	for _, p := range result.Processes {
+		if p.Outcome != want[p.PID] {
+			t.Errorf("PID %s: outcome %s, want %s", p.PID, p.Outcome, want[p.PID])
+		}
+	}
+
+	err := result.err()
+	if !errors.Is(err, ErrStillRunning) {
+		t.Fatalf("err = %v, want ErrStillRunning", err)
+	}
+	if got, want := err.Error(), "cursor processes still running after 3 attempts: [900002 900003 900005]"; got != want {
+		t.Errorf("err = %q, want %q", got, want)
+	}
+}
+
+func TestClassifyAllExited(t *testing.T) {
+	result := &KillResult{Attempts: 1}
+	result.recordSignal("900001", "SIGKILL", nil)
+	// A later "not found" keeps the signal that ended the process
+	result.recordSignal("900001", "", ErrNotFound)
+	result.classify(nil)
+
+	if s := result.Processes[0]; s.Outcome != OutcomeExited || s.Signal != "SIGKILL" {
+		t.Errorf("got %+v, want exited after SIGKILL", s)
+	}
+	if err := result.err(); err != nil {
+		t.Errorf("err = %v, want nil", err)
+	}
+}
@@ -0,0 +1,49 @@
+//go:build !windows
+
+package process
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"os/user"
+	"strings"
+)
+
+// processTable returns the parent, owner and name of every process
+func processTable(ctx context.Context) (map[string]procInfo, error) {
+	out, err := exec.CommandContext(ctx, "ps", "-A", "-o", "pid=,ppid=,uid=,comm=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	table := make(map[string]procInfo)
+	users := make(map[string]string)
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		// The command name may contain spaces, e.g. "Cursor Helper (GPU)"
+		name := strings.TrimSpace(line)
+		for _, f := range fields[:3] {
+			name = strings.TrimSpace(strings.TrimPrefix(name, f))
+		}
+
+		uid := fields[2]
+		if _, ok := users[uid]; !ok {
+			users[uid] = uid
+			if u, err := user.LookupId(uid); err == nil {
+				users[uid] = u.Username
+			}
+		}
+		table[fields[0]] = procInfo{PPID: fields[1], User: users[uid], Name: name}
+	}
+	return table, nil
+}
+
+// processOwners is not needed outside Windows, where processTable already
+// reports owners
+func processOwners(ctx context.Context) (map[string]string, error) {
+	return nil, nil
+}
@@ -0,0 +1,61 @@
+//go:build windows
+
+package process
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+)
+
+// processTable returns the parent and name of every process. Owners are
+// left empty because looking them up through CIM is too slow to do for
+// every process; see processOwners.
+func processTable(ctx context.Context) (map[string]procInfo, error) {
+	script := "Get-CimInstance Win32_Process | Select-Object ProcessId,ParentProcessId,Name | ConvertTo-Json -Compress"
+	out, err := exec.CommandContext(ctx, "powershell", "-NoProfile", "-Command", script).Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	var entries []struct {
+		ProcessId       int
+		ParentProcessId int
+		Name            string
+	}
+	if err := json.Unmarshal(out, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse process list: %w", err)
+	}
+
+	table := make(map[string]procInfo, len(entries))
+	for _, e := range entries {
+		table[strconv.Itoa(e.ProcessId)] = procInfo{PPID: strconv.Itoa(e.ParentProcessId), Name: e.Name}
+	}
+	return table, nil
+}
+
+// processOwners returns the owner of every process from the verbose task
+// list. Owners of processes in other sessions may be reported as N/A.
+func processOwners(ctx context.Context) (map[string]string, error) {
+	out, err := exec.CommandContext(ctx, "tasklist", "/V", "/FO", "CSV", "/NH").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(out)).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse process list: %w", err)
+	}
+	owners := make(map[string]string, len(records))
+	for _, record := range records {
+		// Image Name, PID, Session Name, Session#, Mem Usage, Status, User Name, ...
+		if len(record) > 6 {
+			owners[record[1]] = record[6]
+		}
+	}
+	return owners, nil
+}
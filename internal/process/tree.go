@@ -0,0 +1,106 @@
+package process
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// procInfo is an entry of the system process table
+type procInfo struct {
+	PPID string
+	User string
+	Name string
+}
+
+// Node is a Cursor process in a process tree
+type Node struct {
+	PID      string  `json:"pid"`
+	PPID     string  `json:"ppid,omitempty"`
+	Name     string  `json:"name,omitempty"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+// Tree arranges Cursor processes by parent. Roots are processes whose
+// parent is not a Cursor process, normally the Electron main process.
+type Tree struct {
+	Roots []*Node `json:"roots"`
+}
+
+// buildTree arranges pids using the parent links in table. Processes
+// missing from table become roots.
+func buildTree(pids []string, table map[string]procInfo) *Tree {
+	nodes := make(map[string]*Node, len(pids))
+	for _, pid := range pids {
+		info := table[pid]
+		nodes[pid] = &Node{PID: pid, PPID: info.PPID, Name: info.Name}
+	}
+
+	tree := &Tree{}
+	for _, pid := range pids {
+		node := nodes[pid]
+		if parent, ok := nodes[node.PPID]; ok && node.PPID != pid {
+			parent.Children = append(parent.Children, node)
+		} else {
+			tree.Roots = append(tree.Roots, node)
+		}
+	}
+	return tree
+}
+
+// RootPIDs returns the PIDs of the root processes
+func (t *Tree) RootPIDs() []string {
+	pids := make([]string, len(t.Roots))
+	for i, root := range t.Roots {
+		pids[i] = root.PID
+	}
+	return pids
+}
+
+// Order returns every PID with parents before their children
+func (t *Tree) Order() []string {
+	var pids []string
+	queue := append([]*Node(nil), t.Roots...)
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = append(queue[1:], node.Children...)
+		pids = append(pids, node.PID)
+	}
+	return pids
+}
+
+// String draws the tree with one process per line
+func (t *Tree) String() string {
+	var b strings.Builder
+	var draw func(node *Node, prefix, branch string)
+	draw = func(node *Node, prefix, branch string) {
+		fmt.Fprintf(&b, "%s%s%s %s\n", prefix, branch, node.PID, node.Name)
+		switch branch {
+		case "├─ ":
+			prefix += "│  "
+		case "└─ ":
+			prefix += "   "
+		}
+		children := append([]*Node(nil), node.Children...)
+		sort.Slice(children, func(i, j int) bool { return pidLess(children[i].PID, children[j].PID) })
+		for i, child := range children {
+			if i == len(children)-1 {
+				draw(child, prefix, "└─ ")
+			} else {
+				draw(child, prefix, "├─ ")
+			}
+		}
+	}
+	for _, root := range t.Roots {
+		draw(root, "", "")
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// pidLess orders PIDs numerically
+func pidLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
@@ -0,0 +1,83 @@
+package process
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Cursor main process 100 with a GPU process, a renderer and the renderer's
+// helper, plus a second instance 200 whose parent is not a Cursor process
+var testTable = map[string]procInfo{
+	"100": {PPID: "1", Name: "Cursor"},
+	"101": {PPID: "100", Name: "Cursor Helper (GPU)"},
+	"102": {PPID: "100", Name: "Cursor Helper (Renderer)"},
+	"103": {PPID: "102", Name: "Cursor Helper"},
+	"200": {PPID: "50", Name: "Cursor"},
+}
+
+func TestBuildTree(t *testing.T) {
+	// Children listed before their parents still end up below them
+	tree := buildTree([]string{"103", "200", "101", "100", "102"}, testTable)
+
+	if got, want := tree.RootPIDs(), []string{"200", "100"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("RootPIDs = %v, want %v", got, want)
+	}
+	want := "200 Cursor\n" +
+		"100 Cursor\n" +
+		"├─ 101 Cursor Helper (GPU)\n" +
+		"└─ 102 Cursor Helper (Renderer)\n" +
+		"   └─ 103 Cursor Helper"
+	if got := tree.String(); got != want {
+		t.Errorf("String =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBuildTreeWithoutTable(t *testing.T) {
+	tree := buildTree([]string{"100", "101"}, nil)
+	if got := tree.RootPIDs(); !reflect.DeepEqual(got, []string{"100", "101"}) {
+		t.Errorf("RootPIDs = %v, want every process as a root", got)
+	}
+}
+
+func TestBuildTreeSelfParent(t *testing.T) {
+	// A process listed as its own parent, as PID 0 is on Windows, is a root
+	tree := buildTree([]string{"0"}, map[string]procInfo{"0": {PPID: "0"}})
+	if len(tree.Roots) != 1 || len(tree.Roots[0].Children) != 0 {
+		t.Errorf("got %+v, want one root without children", tree.Roots)
+	}
+}
+
+func TestTreeOrder(t *testing.T) {
+	tree := buildTree([]string{"100", "101", "102", "103"}, testTable)
+	if got, want := tree.Order(), []string{"100", "101", "102", "103"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Order = %v, want %v", got, want)
+	}
+}
+
+func TestSweepOrder(t *testing.T) {
+	tree := buildTree([]string{"100", "101", "102", "103", "200"}, testTable)
+
+	tests := []struct {
+		name      string
+		remaining []string
+		want      []string
+	}{
+		{"roots before children", []string{"103", "102", "100"}, []string{"100", "102", "103"}},
+		{"only survivors", []string{"103", "101"}, []string{"101", "103"}},
+		{"new processes last", []string{"300", "103", "200"}, []string{"200", "103", "300"}},
+		{"nothing left", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sweepOrder(tree, tt.remaining); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("sweepOrder = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPIDLess(t *testing.T) {
+	if !pidLess("9", "10") || pidLess("10", "9") || pidLess("5", "5") {
+		t.Error("pidLess does not order numerically")
+	}
+}
@@ -0,0 +1,200 @@
+// Package progress reports an operation as a sequence of named steps to
+// any number of listeners, such as the terminal, JSON output and the log
+package progress
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Step names a stage of an operation
+type Step string
+
+// Steps of the reset command, in the order they run
+const (
+	StepDetect     Step = "detect"
+	StepPrivileges Step = "privileges"
+	StepStop       Step = "stop_processes"
+	StepBackup     Step = "backup"
+	StepGenerate   Step = "generate"
+	StepWrite      Step = "write"
+	StepVerify     Step = "verify"
+	StepRestore    Step = "restore" // Only after a failed verification
+)
+
+// Status is the state of a step reported by an event
+type Status string
+
+const (
+	StatusStarted  Status = "started"
+	StatusProgress Status = "progress" // The step is running with a new message
+	StatusDone     Status = "done"
+	StatusSkipped  Status = "skipped"
+	StatusFailed   Status = "failed"
+)
+
+// Ended reports whether the status finishes a step
+func (s Status) Ended() bool {
+	return s == StatusDone || s == StatusSkipped || s == StatusFailed
+}
+
+// Event is a change in the state of a step
+type Event struct {
+	Step     Step          `json:"step"`
+	Status   Status        `json:"status"`
+	Message  string        `json:"message,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Time     time.Time     `json:"time"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"-"` // Time since the step started
+}
+
+// MarshalJSON adds the duration in milliseconds to events that end a step
+func (e Event) MarshalJSON() ([]byte, error) {
+	type event Event
+	out := struct {
+		event
+		DurationMS *float64 `json:"durationMs,omitempty"`
+	}{event: event(e)}
+	if e.Status.Ended() {
+		ms := float64(e.Duration) / float64(time.Millisecond)
+		out.DurationMS = &ms
+	}
+	return json.Marshal(out)
+}
+
+// Listener receives the events of a Reporter
+type Listener interface {
+	HandleEvent(Event)
+}
+
+// ListenerFunc adapts a function to a Listener
+type ListenerFunc func(Event)
+
+// HandleEvent calls f(e)
+func (f ListenerFunc) HandleEvent(e Event) {
+	f(e)
+}
+
+// Reporter delivers step events to its listeners. Events are delivered one
+// at a time, in the order they happened.
+type Reporter struct {
+	mu        sync.Mutex
+	listeners []Listener
+}
+
+// NewReporter creates a reporter with the given listeners
+func NewReporter(listeners ...Listener) *Reporter {
+	return &Reporter{listeners: listeners}
+}
+
+// Subscribe adds a listener for subsequent events
+func (r *Reporter) Subscribe(l Listener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, l)
+}
+
+// Start reports that step has started and returns it for reporting its end
+func (r *Reporter) Start(step Step, message string) *Run {
+	run := &Run{reporter: r, step: step, message: message, started: time.Now()}
+	run.emit(StatusStarted, message, nil)
+	return run
+}
+
+func (r *Reporter) emit(e Event) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, l := range r.listeners {
+		l.HandleEvent(e)
+	}
+}
+
+// Run is a started step. Only the first of Done, Skip and Fail is
+// reported; later calls are ignored.
+type Run struct {
+	reporter *Reporter
+	step     Step
+	message  string
+	started  time.Time
+	mu       sync.Mutex
+	ended    bool
+}
+
+// Update reports a new message for the running step
+func (s *Run) Update(message string) {
+	s.emit(StatusProgress, message, nil)
+}
+
+// Done reports that the step succeeded
+func (s *Run) Done() {
+	s.emit(StatusDone, "", nil)
+}
+
+// Skip reports that the step was not needed, and why
+func (s *Run) Skip(reason string) {
+	s.emit(StatusSkipped, reason, nil)
+}
+
+// Fail reports that the step failed with err
+func (s *Run) Fail(err error) {
+	s.emit(StatusFailed, "", err)
+}
+
+// End reports Done if err is nil and Fail otherwise
+func (s *Run) End(err error) {
+	if err != nil {
+		s.Fail(err)
+	} else {
+		s.Done()
+	}
+}
+
+func (s *Run) emit(status Status, message string, err error) {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = status.Ended()
+	if message == "" {
+		message = s.message
+	} else if status == StatusProgress {
+		s.message = message
+	}
+	s.mu.Unlock()
+
+	now := time.Now()
+	e := Event{Step: s.step, Status: status, Message: message, Time: now, Started: s.started, Duration: now.Sub(s.started)}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	s.reporter.emit(e)
+}
+
+// NewJSONListener writes each event to w as a line of JSON
+func NewJSONListener(w io.Writer) Listener {
+	encoder := json.NewEncoder(w)
+	return ListenerFunc(func(e Event) {
+		encoder.Encode(e)
+	})
+}
+
+// NewLogListener logs each event at debug level. Failures are left to the
+// caller to report, as it knows how serious they are.
+func NewLogListener(log *logrus.Logger) Listener {
+	return ListenerFunc(func(e Event) {
+		entry := log.WithFields(logrus.Fields{"step": e.Step, "status": e.Status})
+		if e.Status.Ended() {
+			entry = entry.WithField("duration", e.Duration.Round(time.Millisecond))
+		}
+		if e.Error != "" {
+			entry = entry.WithField("error", e.Error)
+		}
+		entry.Debug(e.Message)
+	})
+}
@@ -0,0 +1,143 @@
+package progress
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// recorder is a listener that keeps the events it receives
+type recorder struct {
+	events []Event
+}
+
+func (r *recorder) HandleEvent(e Event) {
+	r.events = append(r.events, e)
+}
+
+// summary is the part of an event that does not depend on the clock
+type summary struct {
+	Step    Step
+	Status  Status
+	Message string
+	Error   string
+}
+
+func (r *recorder) summaries() []summary {
+	var out []summary
+	for _, e := range r.events {
+		out = append(out, summary{e.Step, e.Status, e.Message, e.Error})
+	}
+	return out
+}
+
+func TestReporterSuccess(t *testing.T) {
+	rec := &recorder{}
+	r := NewReporter(rec)
+
+	detect := r.Start(StepDetect, "Detecting")
+	detect.Update("Reading storage.json")
+	detect.Done()
+	r.Start(StepStop, "Stopping Cursor").Skip("Cursor is not running")
+	r.Start(StepWrite, "Writing").End(nil)
+
+	want := []summary{
+		{StepDetect, StatusStarted, "Detecting", ""},
+		{StepDetect, StatusProgress, "Reading storage.json", ""},
+		{StepDetect, StatusDone, "Reading storage.json", ""},
+		{StepStop, StatusStarted, "Stopping Cursor", ""},
+		{StepStop, StatusSkipped, "Cursor is not running", ""},
+		{StepWrite, StatusStarted, "Writing", ""},
+		{StepWrite, StatusDone, "Writing", ""},
+	}
+	if got := rec.summaries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReporterFailure(t *testing.T) {
+	rec := &recorder{}
+	r := NewReporter(rec)
+
+	r.Start(StepBackup, "Backing up").Done()
+	verify := r.Start(StepVerify, "Verifying")
+	verify.End(errors.New("telemetry.machineId does not have the new value"))
+	// Only the first end of a step is reported
+	verify.Done()
+	verify.Update("ignored")
+	r.Start(StepRestore, "Restoring the backup").Done()
+
+	want := []summary{
+		{StepBackup, StatusStarted, "Backing up", ""},
+		{StepBackup, StatusDone, "Backing up", ""},
+		{StepVerify, StatusStarted, "Verifying", ""},
+		{StepVerify, StatusFailed, "Verifying", "telemetry.machineId does not have the new value"},
+		{StepRestore, StatusStarted, "Restoring the backup", ""},
+		{StepRestore, StatusDone, "Restoring the backup", ""},
+	}
+	if got := rec.summaries(); !reflect.DeepEqual(got, want) {
+		t.Errorf("events = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReporterTimes(t *testing.T) {
+	rec := &recorder{}
+	run := NewReporter(rec).Start(StepGenerate, "Generating")
+	time.Sleep(time.Millisecond)
+	run.Done()
+
+	started, done := rec.events[0], rec.events[1]
+	if !done.Started.Equal(started.Started) || started.Time.Before(started.Started) {
+		t.Errorf("started at %v and %v, want the time of Start", started.Started, done.Started)
+	}
+	if done.Duration < time.Millisecond || done.Duration != done.Time.Sub(done.Started) {
+		t.Errorf("Duration = %v, want the time since Start", done.Duration)
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	first, second := &recorder{}, &recorder{}
+	r := NewReporter(first)
+	r.Start(StepDetect, "Detecting").Done()
+	r.Subscribe(second)
+	r.Start(StepPrivileges, "Checking privileges").Done()
+
+	if len(first.events) != 4 {
+		t.Errorf("first listener got %d events, want 4", len(first.events))
+	}
+	if got := second.summaries(); len(got) != 2 || got[0].Step != StepPrivileges {
+		t.Errorf("second listener got %+v, want only the later step", got)
+	}
+}
+
+func TestEventJSON(t *testing.T) {
+	tests := []struct {
+		status     Status
+		wantMillis bool
+	}{
+		{StatusStarted, false},
+		{StatusProgress, false},
+		{StatusDone, true},
+		{StatusSkipped, true},
+		{StatusFailed, true},
+	}
+	for _, tt := range tests {
+		data, err := json.Marshal(Event{Step: StepWrite, Status: tt.status, Duration: 1500 * time.Microsecond})
+		if err != nil {
+			t.Fatalf("Marshal: %v", err)
+		}
+		var fields map[string]interface{}
+		if err := json.Unmarshal(data, &fields); err != nil {
+			t.Fatal(err)
+		}
+		ms, ok := fields["durationMs"]
+		if ok != tt.wantMillis || (ok && ms != 1.5) {
+			t.Errorf("%s: durationMs = %v, want it only when the step ended", tt.status, ms)
+		}
+		if fields["step"] != string(StepWrite) || fields["status"] != string(tt.status) {
+			t.Errorf("%s: JSON = %s", tt.status, data)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+package redact
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Formatter wraps a logrus formatter and scrubs registered identifiers
+// from messages and field values before they are formatted
+type Formatter struct {
+	Redactor  *Redactor
+	Formatter logrus.Formatter
+}
+
+// Format implements logrus.Formatter
+func (f *Formatter) Format(entry *logrus.Entry) ([]byte, error) {
+	scrubbed := *entry
+	scrubbed.Message = f.Redactor.Scrub(entry.Message)
+
+	scrubbed.Data = make(logrus.Fields, len(entry.Data))
+	for k, v := range entry.Data {
+		// Errors, slices and the like can hold identifiers too; values
+		// without any keep their type
+		s := fmt.Sprint(v)
+		if clean := f.Redactor.Scrub(s); clean != s {
+			v = clean
+		}
+		scrubbed.Data[k] = v
+	}
+
+	return f.Formatter.Format(&scrubbed)
+}
@@ -0,0 +1,155 @@
+// Package redact masks identifiers before they reach the terminal or logs.
+//
+// Telemetry identifiers are long-lived keys that tend to end up in
+// screenshots, bug reports and CI logs. A Redactor is shared by the UI and
+// the logger so both apply the same policy.
+package redact
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mode selects how identifiers are displayed
+type Mode string
+
+const (
+	// None shows identifiers unchanged
+	None Mode = "none"
+	// Partial keeps the first and last four characters
+	Partial Mode = "partial"
+	// Hash replaces identifiers with a keyed HMAC-SHA256 digest
+	Hash Mode = "hash"
+)
+
+// KeyEnvVar names the environment variable holding the HMAC key. Setting
+// it keeps hashes stable across runs so they can be correlated.
+const KeyEnvVar = "CURSOR_ID_MODIFIER_REDACT_KEY"
+
+const (
+	visibleChars = 4
+	hashChars    = 16
+)
+
+// ParseMode converts a flag value into a Mode
+func ParseMode(s string) (Mode, error) {
+	switch mode := Mode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case None, Partial, Hash:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid redaction mode %q: expected none, partial or hash", s)
+	}
+}
+
+// DefaultMode returns the mode used when none is configured: identifiers
+// are shown in full on a terminal and partially redacted otherwise
+func DefaultMode(isTTY bool) Mode {
+	if isTTY {
+		return None
+	}
+	return Partial
+}
+
+// Redactor masks identifiers according to its mode
+type Redactor struct {
+	mode    Mode
+	key     []byte
+	secrets *secretSet
+}
+
+// secretSet holds the registered identifiers, shared by the redactors
+// derived with WithMode
+type secretSet struct {
+	values map[string]struct{}
+	mu     sync.RWMutex
+}
+
+// New creates a redactor. If key is empty, the key is read from
+// KeyEnvVar or generated randomly for this process.
+func New(mode Mode, key []byte) *Redactor {
+	if len(key) == 0 {
+		key = []byte(os.Getenv(KeyEnvVar))
+	}
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		rand.Read(key)
+	}
+	return &Redactor{
+		mode:    mode,
+		key:     key,
+		secrets: &secretSet{values: make(map[string]struct{})},
+	}
+}
+
+// WithMode returns a redactor with another mode that shares the key and the
+// registered identifiers of r, e.g. for a log file kept for bug reports
+func (r *Redactor) WithMode(mode Mode) *Redactor {
+	return &Redactor{mode: mode, key: r.key, secrets: r.secrets}
+}
+
+// Mode returns the active redaction mode
+func (r *Redactor) Mode() Mode {
+	return r.mode
+}
+
+// Redact masks a single identifier
+func (r *Redactor) Redact(value string) string {
+	if r == nil || value == "" {
+		return value
+	}
+
+	switch r.mode {
+	case Partial:
+		if len(value) <= 2*visibleChars {
+			return strings.Repeat("*", len(value))
+		}
+		return value[:visibleChars] + "…" + value[len(value)-visibleChars:]
+	case Hash:
+		mac := hmac.New(sha256.New, r.key)
+		mac.Write([]byte(value))
+		return "hmac:" + hex.EncodeToString(mac.Sum(nil))[:hashChars]
+	default:
+		return value
+	}
+}
+
+// Register adds identifiers that Scrub should mask wherever they appear
+func (r *Redactor) Register(values ...string) {
+	r.secrets.mu.Lock()
+	defer r.secrets.mu.Unlock()
+	for _, v := range values {
+		if v != "" {
+			r.secrets.values[v] = struct{}{}
+		}
+	}
+}
+
+// Scrub masks every registered identifier found in text
+func (r *Redactor) Scrub(text string) string {
+	if r == nil || r.mode == None {
+		return text
+	}
+
+	r.secrets.mu.RLock()
+	secrets := make([]string, 0, len(r.secrets.values))
+	for s := range r.secrets.values {
+		secrets = append(secrets, s)
+	}
+	r.secrets.mu.RUnlock()
+
+	// Replace longer values first so a value containing another is masked whole
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	for _, s := range secrets {
+		if strings.Contains(text, s) {
+			text = strings.ReplaceAll(text, s, r.Redact(s))
+		}
+	}
+	return text
+}
@@ -0,0 +1,146 @@
+package redact
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+const machineID = "0123456789abcdef0123456789abcdef"
+
+func TestRedactModes(t *testing.T) {
+	key := []byte("test key")
+	tests := []struct {
+		mode  Mode
+		value string
+		want  string
+	}{
+		{None, machineID, machineID},
+		{Partial, machineID, "0123…cdef"},
+		{Partial, "12345678", "********"},
+		{Partial, "abc", "***"},
+		{Partial, "", ""},
+		{Hash, "", ""},
+	}
+	for _, tt := range tests {
+		if got := New(tt.mode, key).Redact(tt.value); got != tt.want {
+			t.Errorf("%s: Redact(%q) = %q, want %q", tt.mode, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRedactHash(t *testing.T) {
+	r := New(Hash, []byte("test key"))
+	got := r.Redact(machineID)
+	if !strings.HasPrefix(got, "hmac:") || len(got) != len("hmac:")+hashChars {
+		t.Fatalf("Redact = %q, want hmac: and %d hex digits", got, hashChars)
+	}
+	if strings.Contains(got, machineID[:8]) {
+		t.Errorf("hash %q leaks the value", got)
+	}
+	if again := New(Hash, []byte("test key")).Redact(machineID); again != got {
+		t.Errorf("same key gave %q and %q", got, again)
+	}
+	if other := New(Hash, []byte("other key")).Redact(machineID); other == got {
+		t.Errorf("different keys gave the same hash %q", got)
+	}
+}
+
+func TestRedactNil(t *testing.T) {
+	var r *Redactor
+	if got := r.Redact(machineID); got != machineID {
+		t.Errorf("nil Redact = %q", got)
+	}
+	if got := r.Scrub(machineID); got != machineID {
+		t.Errorf("nil Scrub = %q", got)
+	}
+}
+
+func TestParseMode(t *testing.T) {
+	for in, want := range map[string]Mode{"none": None, " Partial ": Partial, "HASH": Hash} {
+		if got, err := ParseMode(in); err != nil || got != want {
+			t.Errorf("ParseMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseMode("full"); err == nil {
+		t.Error("ParseMode(full) succeeded")
+	}
+}
+
+func TestScrub(t *testing.T) {
+	short := machineID[:12]
+	r := New(Partial, []byte("k"))
+	r.Register(machineID, short, "")
+
+	text := "id=" + machineID + " prefix=" + short
+	want := "id=0123…cdef prefix=0123…89ab"
+	if got := r.Scrub(text); got != want {
+		t.Errorf("Scrub = %q, want %q", got, want)
+	}
+
+	if got := r.WithMode(None).Scrub(text); got != text {
+		t.Errorf("None Scrub = %q, want the text unchanged", got)
+	}
+	hashed := r.WithMode(Hash).Scrub(text)
+	if strings.Contains(hashed, machineID) || strings.Contains(hashed, short) {
+		t.Errorf("Hash Scrub = %q leaks a registered value", hashed)
+	}
+}
+
+func TestWithModeSharesRegistrations(t *testing.T) {
+	r := New(None, []byte("k"))
+	partial := r.WithMode(Partial)
+	r.Register(machineID)
+	if got := partial.Scrub(machineID); got != "0123…cdef" {
+		t.Errorf("Scrub = %q, want the value registered on the parent masked", got)
+	}
+	if partial.Mode() != Partial || r.Mode() != None {
+		t.Errorf("modes = %s, %s", partial.Mode(), r.Mode())
+	}
+}
+
+func TestFormatterScrubsFields(t *testing.T) {
+	r := New(Partial, []byte("k"))
+	r.Register(machineID)
+	var formatted *logrus.Entry
+	f := &Formatter{Redactor: r, Formatter: formatterFunc(func(e *logrus.Entry) ([]byte, error) {
+		formatted = e
+		return nil, nil
+	})}
+
+	entry := &logrus.Entry{
+		Message: "wrote " + machineID,
+		Data: logrus.Fields{
+			"string": machineID,
+			"error":  errors.New("bad value " + machineID),
+			"args":   []string{"set", machineID},
+			"count":  3,
+		},
+	}
+	if _, err := f.Format(entry); err != nil {
+		t.Fatalf("Format: %v", err)
+	}
+
+	if formatted.Message != "wrote 0123…cdef" {
+		t.Errorf("Message = %q", formatted.Message)
+	}
+	want := logrus.Fields{
+		"string": "0123…cdef",
+		"error":  "bad value 0123…cdef",
+		"args":   "[set 0123…cdef]",
+		"count":  3, // Nothing to scrub, so the value keeps its type
+	}
+	if !reflect.DeepEqual(formatted.Data, want) {
+		t.Errorf("Data = %#v, want %#v", formatted.Data, want)
+	}
+	if entry.Data["string"] != machineID {
+		t.Error("Format modified the original entry")
+	}
+}
+
+type formatterFunc func(*logrus.Entry) ([]byte, error)
+
+func (f formatterFunc) Format(e *logrus.Entry) ([]byte, error) { return f(e) }
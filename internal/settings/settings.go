@@ -0,0 +1,359 @@
+// Package settings loads the configuration of the tool itself.
+//
+// Settings are layered with the following precedence, highest first:
+// command-line flags, environment variables, the per-user config file,
+// the system-wide config file and built-in defaults. Config files may be
+// written in TOML, YAML or JSON; the format is chosen by file extension.
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/yuaotian/go-cursor-help/internal/paths"
+	"github.com/yuaotian/go-cursor-help/internal/process"
+)
+
+// EnvPrefix prefixes the environment variables that override settings
+const EnvPrefix = "CURSOR_ID_MODIFIER_"
+
+// ConfigEnvVar names an explicit config file, replacing the user config file
+const ConfigEnvVar = EnvPrefix + "CONFIG"
+
+// fileBaseName is the config file name without extension
+const fileBaseName = "config"
+
+// extensions lists the supported config file extensions in lookup order
+var extensions = []string{".toml", ".yaml", ".yml", ".json"}
+
+// Output formats
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// Settings is the effective configuration of the tool
+type Settings struct {
+	Language  string          `json:"language"`
+	LocaleDir string          `json:"locale_dir"` // Directory of gettext catalogs overriding the built-in ones
+	Output    string          `json:"output"`
+	Redact    string          `json:"redact"`
+	Process   ProcessSettings `json:"process"`
+	Backup    BackupSettings  `json:"backup"`
+	Log       LogSettings     `json:"log"`
+}
+
+// ProcessSettings configures how Cursor processes are found and closed
+type ProcessSettings struct {
+	MaxAttempts int      `json:"max_attempts"`
+	RetryDelay  Duration `json:"retry_delay"`
+	Patterns    []string `json:"patterns"`
+	Exclude     []string `json:"exclude"` // Denylist of processes never closed
+}
+
+// BackupSettings configures storage.json backups
+type BackupSettings struct {
+	Retention int `json:"retention"` // Number of backups to keep; 0 keeps all
+}
+
+// LogSettings configures the log on stderr and the log file
+type LogSettings struct {
+	Level     string `json:"level"`       // Level on stderr: trace, debug, info, warn or error
+	Format    string `json:"format"`      // text or json
+	File      string `json:"file"`        // Empty for the default file in the log directory, "off" to disable
+	MaxSizeMB int    `json:"max_size_mb"` // Size at which the file is rotated; 0 disables rotation
+	MaxFiles  int    `json:"max_files"`   // Rotated files kept
+}
+
+// LogFileOff disables the log file when used as LogSettings.File
+const LogFileOff = "off"
+
+// Duration is a time.Duration written as a string such as "2s"
+type Duration time.Duration
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON accepts a duration string or a number of seconds
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		parsed, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q: %w", s, err)
+		}
+		*d = Duration(parsed)
+		return nil
+	}
+	var seconds float64
+	if err := json.Unmarshal(data, &seconds); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	*d = Duration(seconds * float64(time.Second))
+	return nil
+}
+
+// Default returns the built-in settings
+func Default() *Settings {
+	proc := process.DefaultConfig()
+	return &Settings{
+		Output: OutputText,
+		Process: ProcessSettings{
+			MaxAttempts: proc.MaxAttempts,
+			RetryDelay:  Duration(proc.RetryDelay),
+			Patterns:    proc.ProcessPatterns,
+			Exclude:     proc.ExcludePatterns,
+		},
+		Log: LogSettings{
+			Level:     "info",
+			Format:    OutputText,
+			MaxSizeMB: 5,
+			MaxFiles:  3,
+		},
+	}
+}
+
+// ProcessConfig converts the process settings for process.NewManager
+func (s *Settings) ProcessConfig() *process.Config {
+	return &process.Config{
+		MaxAttempts:     s.Process.MaxAttempts,
+		RetryDelay:      time.Duration(s.Process.RetryDelay),
+		ProcessPatterns: s.Process.Patterns,
+		ExcludePatterns: s.Process.Exclude,
+	}
+}
+
+// Flags holds the settings given on the command line. Empty values are
+// not set and leave the lower layers in effect.
+type Flags struct {
+	Language  string
+	Output    string
+	Redact    string
+	Verbose   bool // Same as LogLevel "debug"; an explicit LogLevel wins
+	LogLevel  string
+	LogFile   string
+	LogFormat string
+}
+
+// Loaded is the result of Load
+type Loaded struct {
+	Settings *Settings
+	Files    []string // Config files that were applied, lowest precedence first
+}
+
+// Load builds the settings for username from defaults, config files, the
+// environment and flags. A non-empty explicitPath replaces the per-user
+// config file.
+func Load(username, explicitPath string, flags Flags) (*Loaded, error) {
+	loaded := &Loaded{Settings: Default()}
+
+	files, err := configFiles(username, explicitPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		values, err := readFile(file)
+		if err != nil {
+			return nil, err
+		}
+		if err := apply(loaded.Settings, values); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		loaded.Files = append(loaded.Files, file)
+	}
+
+	if err := applyEnv(loaded.Settings); err != nil {
+		return nil, err
+	}
+	applyFlags(loaded.Settings, flags)
+	return loaded, loaded.Settings.Validate()
+}
+
+// Validate checks that the settings are usable
+func (s *Settings) Validate() error {
+	switch s.Output {
+	case OutputText, OutputJSON:
+	default:
+		return fmt.Errorf("invalid output format %q: expected text or json", s.Output)
+	}
+	if s.Process.MaxAttempts < 1 {
+		return fmt.Errorf("process.max_attempts must be at least 1")
+	}
+	if s.Process.RetryDelay < 0 {
+		return fmt.Errorf("process.retry_delay must not be negative")
+	}
+	if len(s.Process.Patterns) == 0 {
+		return fmt.Errorf("process.patterns must not be empty")
+	}
+	if s.Backup.Retention < 0 {
+		return fmt.Errorf("backup.retention must not be negative")
+	}
+	if _, err := logrus.ParseLevel(s.Log.Level); err != nil {
+		return fmt.Errorf("invalid log level %q: expected trace, debug, info, warn or error", s.Log.Level)
+	}
+	switch s.Log.Format {
+	case OutputText, OutputJSON:
+	default:
+		return fmt.Errorf("invalid log format %q: expected text or json", s.Log.Format)
+	}
+	if s.Log.MaxSizeMB < 0 {
+		return fmt.Errorf("log.max_size_mb must not be negative")
+	}
+	if s.Log.MaxFiles < 0 {
+		return fmt.Errorf("log.max_files must not be negative")
+	}
+	return nil
+}
+
+// configFiles returns the existing config files in precedence order,
+// lowest first
+func configFiles(username, explicitPath string) ([]string, error) {
+	var files []string
+	if file := findFile(paths.SystemConfigDir()); file != "" {
+		files = append(files, file)
+	}
+
+	if explicitPath == "" {
+		explicitPath = os.Getenv(ConfigEnvVar)
+	}
+	if explicitPath != "" {
+		if _, err := os.Stat(explicitPath); err != nil {
+			return nil, fmt.Errorf("config file: %w", err)
+		}
+		return append(files, explicitPath), nil
+	}
+
+	dir, err := paths.ConfigDir(username)
+	if err != nil {
+		return nil, err
+	}
+	if file := findFile(dir); file != "" {
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// findFile returns the first config file present in dir
+func findFile(dir string) string {
+	for _, ext := range extensions {
+		path := filepath.Join(dir, fileBaseName+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// readFile parses a config file according to its extension
+func readFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var values map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		values, err = parseTOML(data)
+	case ".yaml", ".yml":
+		values, err = parseYAML(data)
+	case ".json":
+		err = json.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("%s: unsupported config format", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return values, nil
+}
+
+// apply overlays values onto s. Keys that are absent leave s unchanged.
+func apply(s *Settings, values map[string]interface{}) error {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	return dec.Decode(s)
+}
+
+// applyEnv overlays settings from CURSOR_ID_MODIFIER_* environment variables
+func applyEnv(s *Settings) error {
+	str := func(name string, target *string) {
+		if value, ok := os.LookupEnv(EnvPrefix + name); ok {
+			*target = value
+		}
+	}
+	integer := func(name string, target *int) error {
+		if value, ok := os.LookupEnv(EnvPrefix + name); ok {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s%s: %w", EnvPrefix, name, err)
+			}
+			*target = n
+		}
+		return nil
+	}
+
+	str("LANGUAGE", &s.Language)
+	str("LOCALE_DIR", &s.LocaleDir)
+	str("OUTPUT", &s.Output)
+	str("REDACT", &s.Redact)
+	if err := integer("PROCESS_MAX_ATTEMPTS", &s.Process.MaxAttempts); err != nil {
+		return err
+	}
+	if value, ok := os.LookupEnv(EnvPrefix + "PROCESS_RETRY_DELAY"); ok {
+		if err := s.Process.RetryDelay.UnmarshalJSON([]byte(strconv.Quote(value))); err != nil {
+			return fmt.Errorf("%sPROCESS_RETRY_DELAY: %w", EnvPrefix, err)
+		}
+	}
+	if value, ok := os.LookupEnv(EnvPrefix + "PROCESS_PATTERNS"); ok {
+		s.Process.Patterns = strings.Split(value, ",")
+	}
+	if value, ok := os.LookupEnv(EnvPrefix + "PROCESS_EXCLUDE"); ok {
+		s.Process.Exclude = nil
+		if value != "" {
+			s.Process.Exclude = strings.Split(value, ",")
+		}
+	}
+	if err := integer("BACKUP_RETENTION", &s.Backup.Retention); err != nil {
+		return err
+	}
+	str("LOG_LEVEL", &s.Log.Level)
+	str("LOG_FORMAT", &s.Log.Format)
+	str("LOG_FILE", &s.Log.File)
+	if err := integer("LOG_MAX_SIZE_MB", &s.Log.MaxSizeMB); err != nil {
+		return err
+	}
+	return integer("LOG_MAX_FILES", &s.Log.MaxFiles)
+}
+
+// applyFlags overlays the settings given on the command line
+func applyFlags(s *Settings, flags Flags) {
+	str := func(value string, target *string) {
+		if value != "" {
+			*target = value
+		}
+	}
+
+	str(flags.Language, &s.Language)
+	str(flags.Output, &s.Output)
+	str(flags.Redact, &s.Redact)
+	if flags.Verbose {
+		s.Log.Level = "debug"
+	}
+	str(flags.LogLevel, &s.Log.Level)
+	str(flags.LogFile, &s.Log.File)
+	str(flags.LogFormat, &s.Log.Format)
+}
@@ -0,0 +1,169 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv unsets the settings environment variables for the test
+func clearEnv(t *testing.T) {
+	t.Helper()
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, EnvPrefix) {
+			t.Setenv(name, "")
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	clearEnv(t)
+	path := writeConfig(t, "config.toml", `
+language = "de"
+output = "json"
+redact = "hash"
+
+[log]
+level = "warn"
+format = "json"
+
+[process]
+max_attempts = 7
+`)
+	t.Setenv(EnvPrefix+"REDACT", "partial")
+	t.Setenv(EnvPrefix+"LOG_LEVEL", "error")
+	t.Setenv(EnvPrefix+"PROCESS_RETRY_DELAY", "250ms")
+
+	loaded, err := Load("nobody", path, Flags{Redact: "none", LogFormat: "text"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	s := loaded.Settings
+	defaults := Default()
+
+	checks := []struct {
+		name      string
+		got, want interface{}
+	}{
+		{"flag over env and file", s.Redact, "none"},
+		{"flag over file", s.Log.Format, "text"},
+		{"env over file", s.Log.Level, "error"},
+		{"env over default", time.Duration(s.Process.RetryDelay), 250 * time.Millisecond},
+		{"file over default", s.Output, "json"},
+		{"file over default", s.Language, "de"},
+		{"file over default", s.Process.MaxAttempts, 7},
+		{"default", s.Process.Patterns, defaults.Process.Patterns},
+		{"default", s.Log.MaxFiles, defaults.Log.MaxFiles},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s: got %v, want %v", c.name, c.got, c.want)
+		}
+	}
+	if !reflect.DeepEqual(loaded.Files, []string{path}) {
+		t.Errorf("Files = %v, want [%s]", loaded.Files, path)
+	}
+}
+
+func TestLoadVerboseFlag(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(EnvPrefix+"LOG_LEVEL", "warn")
+
+	tests := []struct {
+		flags Flags
+		want  string
+	}{
+		{Flags{}, "warn"},
+		{Flags{Verbose: true}, "debug"},
+		{Flags{Verbose: true, LogLevel: "trace"}, "trace"},
+	}
+	for _, tt := range tests {
+		loaded, err := Load("nobody", writeConfig(t, "config.json", "{}"), tt.flags)
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if got := loaded.Settings.Log.Level; got != tt.want {
+			t.Errorf("flags %+v: level = %q, want %q", tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFormats(t *testing.T) {
+	clearEnv(t)
+	for name, content := range map[string]string{
+		"config.toml": "[backup]\nretention = 010\n",
+		"config.yaml": "backup:\n  retention: 010\n",
+		"config.yml":  "backup:\n  retention: 0xA\n",
+		"config.json": `{"backup": {"retention": 10}}`,
+	} {
+		loaded, err := Load("nobody", writeConfig(t, name, content), Flags{})
+		if err != nil {
+			t.Fatalf("%s: Load: %v", name, err)
+		}
+		if got := loaded.Settings.Backup.Retention; got != 10 {
+			t.Errorf("%s: retention = %d, want 10", name, got)
+		}
+	}
+}
+
+func TestLoadConfigEnvVar(t *testing.T) {
+	clearEnv(t)
+	t.Setenv(ConfigEnvVar, writeConfig(t, "config.yaml", "output: json\n"))
+	loaded, err := Load("nobody", "", Flags{})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if loaded.Settings.Output != OutputJSON {
+		t.Errorf("Output = %q, want json from the file named by %s", loaded.Settings.Output, ConfigEnvVar)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		env     map[string]string
+		flags   Flags
+		want    string
+	}{
+		{name: "unknown key", file: "config.toml", content: "colour = true", want: "unknown field"},
+		{name: "wrong type", file: "config.yaml", content: "backup:\n  retention: yes", want: "retention"},
+		{name: "parse error", file: "config.toml", content: "a = ", want: "line 1"},
+		{name: "invalid file value", file: "config.json", content: `{"process": {"max_attempts": 0}}`, want: "max_attempts"},
+		{name: "invalid env value", file: "config.json", content: "{}",
+			env: map[string]string{EnvPrefix + "BACKUP_RETENTION": "many"}, want: "BACKUP_RETENTION"},
+		{name: "invalid flag value", file: "config.json", content: "{}", flags: Flags{Output: "xml"}, want: "invalid output format"},
+		{name: "unsupported format", file: "config.ini", content: "", want: "unsupported config format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			_, err := Load("nobody", writeConfig(t, tt.file, tt.content), tt.flags)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+
+	clearEnv(t)
+	if _, err := Load("nobody", filepath.Join(t.TempDir(), "missing.toml"), Flags{}); err == nil {
+		t.Error("Load with a missing explicit file succeeded")
+	}
+}
@@ -0,0 +1,264 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML parses the subset of TOML used by config files: tables,
+// dotted keys, strings, integers, floats, booleans and arrays
+func parseTOML(data []byte) (map[string]interface{}, error) {
+	root := make(map[string]interface{})
+	table := root
+
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNo := i + 1
+		line := strings.TrimSpace(stripComment(lines[i], '#'))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: unsupported table header %q", lineNo, line)
+			}
+			keys, err := splitKey(line[1 : len(line)-1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			if table, err = subTable(root, keys); err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			continue
+		}
+
+		eq := indexOutsideQuotes(line, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", lineNo)
+		}
+		keys, err := splitKey(line[:eq])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+
+		// Arrays may continue over several lines until brackets balance
+		raw := strings.TrimSpace(line[eq+1:])
+		for bracketDepth(raw) > 0 && i+1 < len(lines) {
+			i++
+			raw += " " + strings.TrimSpace(stripComment(lines[i], '#'))
+		}
+
+		value, rest, err := parseTOMLValue(raw)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if strings.TrimSpace(rest) != "" {
+			return nil, fmt.Errorf("line %d: unexpected %q after value", lineNo, rest)
+		}
+
+		parent, err := subTable(table, keys[:len(keys)-1])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		last := keys[len(keys)-1]
+		if _, exists := parent[last]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %q", lineNo, last)
+		}
+		parent[last] = value
+	}
+	return root, nil
+}
+
+// parseTOMLValue parses one value from the start of s and returns the rest
+func parseTOMLValue(s string) (interface{}, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, "", fmt.Errorf("missing value")
+	}
+
+	switch s[0] {
+	case '"', '\'':
+		return parseQuoted(s)
+	case '[':
+		var items []interface{}
+		rest := strings.TrimSpace(s[1:])
+		for {
+			if strings.HasPrefix(rest, "]") {
+				return items, rest[1:], nil
+			}
+			item, r, err := parseTOMLValue(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			items = append(items, item)
+			rest = strings.TrimSpace(r)
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, "", fmt.Errorf("expected ',' or ']' in array")
+			}
+		}
+	}
+
+	end := strings.IndexAny(s, ",]")
+	if end < 0 {
+		end = len(s)
+	}
+	token, rest := strings.TrimSpace(s[:end]), s[end:]
+	switch token {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	clean := strings.ReplaceAll(token, "_", "")
+	if n, err := parseInteger(clean); err == nil {
+		return n, rest, nil
+	}
+	if f, err := strconv.ParseFloat(clean, 64); err == nil {
+		return f, rest, nil
+	}
+	return nil, "", fmt.Errorf("invalid value %q", token)
+}
+
+// parseInteger parses a decimal integer, or a hexadecimal, octal or binary
+// one written with an explicit 0x, 0o or 0b prefix. A leading zero alone
+// does not make a number octal, so 010 is ten.
+func parseInteger(s string) (int64, error) {
+	if len(s) > 2 && s[0] == '0' && strings.IndexByte("xob", s[1]) >= 0 {
+		return strconv.ParseInt(s, 0, 64)
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+// parseQuoted parses a basic ("...") or literal ('...') string
+func parseQuoted(s string) (string, string, error) {
+	quote := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			if quote == '\'' {
+				return s[1:i], s[i+1:], nil
+			}
+			value, err := strconv.Unquote(s[:i+1])
+			if err != nil {
+				return "", "", fmt.Errorf("invalid string %s: %w", s[:i+1], err)
+			}
+			return value, s[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("unterminated string")
+}
+
+// splitKey splits a possibly dotted and quoted key
+func splitKey(s string) ([]string, error) {
+	var keys []string
+	s = strings.TrimSpace(s)
+	for s != "" {
+		var key string
+		if s[0] == '"' || s[0] == '\'' {
+			var err error
+			if key, s, err = parseQuoted(s); err != nil {
+				return nil, err
+			}
+		} else {
+			end := strings.IndexByte(s, '.')
+			if end < 0 {
+				end = len(s)
+			}
+			key, s = strings.TrimSpace(s[:end]), s[end:]
+			if key == "" {
+				return nil, fmt.Errorf("empty key")
+			}
+		}
+		keys = append(keys, key)
+
+		s = strings.TrimSpace(s)
+		if strings.HasPrefix(s, ".") {
+			s = strings.TrimSpace(s[1:])
+			if s == "" {
+				return nil, fmt.Errorf("key ends with '.'")
+			}
+		} else if s != "" {
+			return nil, fmt.Errorf("invalid key")
+		}
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("empty key")
+	}
+	return keys, nil
+}
+
+// subTable walks or creates nested tables below table
+func subTable(table map[string]interface{}, keys []string) (map[string]interface{}, error) {
+	for _, key := range keys {
+		next, exists := table[key]
+		if !exists {
+			child := make(map[string]interface{})
+			table[key] = child
+			table = child
+			continue
+		}
+		child, ok := next.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("key %q is not a table", key)
+		}
+		table = child
+	}
+	return table, nil
+}
+
+// stripComment removes a trailing comment that starts outside quotes
+func stripComment(line string, marker byte) string {
+	if i := indexOutsideQuotes(line, marker); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+// indexOutsideQuotes returns the index of the first c not inside a string
+func indexOutsideQuotes(s string, c byte) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == c:
+			return i
+		}
+	}
+	return -1
+}
+
+// bracketDepth returns the number of unclosed '[' outside strings
+func bracketDepth(s string) int {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		switch {
+		case quote == '"' && s[i] == '\\':
+			i++
+		case quote != 0:
+			if s[i] == quote {
+				quote = 0
+			}
+		case s[i] == '"' || s[i] == '\'':
+			quote = s[i]
+		case s[i] == '[':
+			depth++
+		case s[i] == ']':
+			depth--
+		}
+	}
+	return depth
+}
@@ -0,0 +1,70 @@
+package settings
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type m = map[string]interface{}
+
+func TestParseTOML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]interface{}
+	}{
+		{"empty", "# nothing\n\n", m{}},
+		{"scalars", "a = \"x\"\nb = 'C:\\path'\nc = 42\nd = -1.5\ne = true\nf = false\n",
+			m{"a": "x", "b": `C:\path`, "c": int64(42), "d": -1.5, "e": true, "f": false}},
+		{"integers are decimal", "a = 010\nb = 1_000\nc = +7", m{"a": int64(10), "b": int64(1000), "c": int64(7)}},
+		{"explicit bases", "a = 0x1F\nb = 0o17\nc = 0b101", m{"a": int64(31), "b": int64(15), "c": int64(5)}},
+		{"escapes", `a = "tab\there \"q\" \u00e9"`, m{"a": "tab\there \"q\" é"}},
+		{"comments", "a = \"x # not a comment\" # comment\n# b = 1\n", m{"a": "x # not a comment"}},
+		{"tables and dotted keys", "top = 1\n[process]\nmax_attempts = 3\nretry.delay = \"1s\"\n[log]\nlevel = \"debug\"\n",
+			m{"top": int64(1), "process": m{"max_attempts": int64(3), "retry": m{"delay": "1s"}}, "log": m{"level": "debug"}}},
+		{"nested table header", "[a.b]\nc = 1\n[a]\nd = 2\n", m{"a": m{"b": m{"c": int64(1)}, "d": int64(2)}}},
+		{"quoted keys", "\"a.b\" = 1\n'c' = 2\n", m{"a.b": int64(1), "c": int64(2)}},
+		{"arrays", "a = [1, \"two\", [3]]\nb = []\n", m{"a": []interface{}{int64(1), "two", []interface{}{int64(3)}}, "b": []interface{}(nil)}},
+		{"multi-line array", "a = [\n  \"x\", # first\n  \"y\",\n]\n", m{"a": []interface{}{"x", "y"}}},
+		{"CRLF", "a = 1\r\nb = 2\r\n", m{"a": int64(1), "b": int64(2)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTOML([]byte(tt.src))
+			if err != nil {
+				t.Fatalf("parseTOML: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTOMLErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"missing value", "a =", "line 1: missing value"},
+		{"no equals", "a\n", "line 1: expected key = value"},
+		{"duplicate key", "a = 1\na = 2", `line 2: duplicate key "a"`},
+		{"duplicate in table", "[t]\na = 1\n[t]\na = 2", `line 4: duplicate key "a"`},
+		{"key is not a table", "a = 1\n[a]", `line 2: key "a" is not a table`},
+		{"array of tables", "[[a]]", "unsupported table header"},
+		{"unterminated string", `a = "x`, "unterminated string"},
+		{"bare word", "a = yes", `invalid value "yes"`},
+		{"trailing text", `a = "x" y`, "unexpected"},
+		{"empty key part", "a..b = 1", "empty key"},
+		{"unclosed array", "a = [1, 2", "expected ',' or ']'"},
+		{"invalid hex", "a = 0xZZ", "invalid value"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseTOML([]byte(tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+package settings
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a non-empty line of a YAML document with its indentation
+type yamlLine struct {
+	no     int
+	indent int
+	text   string
+}
+
+// parseYAML parses the subset of YAML used by config files: nested
+// mappings, block and flow sequences, and plain or quoted scalars
+func parseYAML(data []byte) (map[string]interface{}, error) {
+	var lines []yamlLine
+	for i, raw := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		if strings.Contains(raw, "\t") && strings.TrimLeft(raw, " \t") != strings.TrimLeft(raw, " ") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		text := strings.TrimRight(stripYAMLComment(raw), " \t")
+		trimmed := strings.TrimLeft(text, " ")
+		if trimmed == "" || trimmed == "---" {
+			continue
+		}
+		lines = append(lines, yamlLine{no: i + 1, indent: len(text) - len(trimmed), text: trimmed})
+	}
+	if len(lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	value, next, err := parseYAMLBlock(lines, 0, lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if next < len(lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", lines[next].no)
+	}
+	root, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("top-level value must be a mapping")
+	}
+	return root, nil
+}
+
+// parseYAMLBlock parses the mapping or sequence starting at lines[i]
+func parseYAMLBlock(lines []yamlLine, i, indent int) (interface{}, int, error) {
+	if strings.HasPrefix(lines[i].text, "- ") || lines[i].text == "-" {
+		var items []interface{}
+		for i < len(lines) && lines[i].indent == indent && (strings.HasPrefix(lines[i].text, "- ") || lines[i].text == "-") {
+			item := strings.TrimSpace(strings.TrimPrefix(lines[i].text, "-"))
+			if item == "" {
+				if i+1 >= len(lines) || lines[i+1].indent <= indent {
+					items = append(items, nil)
+					i++
+					continue
+				}
+				value, next, err := parseYAMLBlock(lines, i+1, lines[i+1].indent)
+				if err != nil {
+					return nil, 0, err
+				}
+				items = append(items, value)
+				i = next
+				continue
+			}
+			value, err := parseYAMLScalar(item)
+			if err != nil {
+				return nil, 0, fmt.Errorf("line %d: %w", lines[i].no, err)
+			}
+			items = append(items, value)
+			i++
+		}
+		return items, i, nil
+	}
+
+	mapping := make(map[string]interface{})
+	for i < len(lines) && lines[i].indent == indent {
+		line := lines[i]
+		colon := indexOutsideQuotes(line.text, ':')
+		for colon >= 0 && colon+1 < len(line.text) && line.text[colon+1] != ' ' {
+			// A colon not followed by a space is part of a plain scalar
+			next := indexOutsideQuotes(line.text[colon+1:], ':')
+			if next < 0 {
+				colon = -1
+				break
+			}
+			colon += 1 + next
+		}
+		if colon < 0 {
+			return nil, 0, fmt.Errorf("line %d: expected key: value", line.no)
+		}
+
+		key := strings.TrimSpace(line.text[:colon])
+		if unquoted, err := parseYAMLScalar(key); err == nil {
+			if s, ok := unquoted.(string); ok {
+				key = s
+			}
+		}
+		if _, exists := mapping[key]; exists {
+			return nil, 0, fmt.Errorf("line %d: duplicate key %q", line.no, key)
+		}
+
+		rest := strings.TrimSpace(line.text[colon+1:])
+		if rest != "" {
+			value, err := parseYAMLScalar(rest)
+			if err != nil {
+				return nil, 0, fmt.Errorf("line %d: %w", line.no, err)
+			}
+			mapping[key] = value
+			i++
+			continue
+		}
+
+		// A nested block is more indented, except that a sequence may sit
+		// at the same indentation as its key
+		if i+1 < len(lines) && (lines[i+1].indent > indent ||
+			(lines[i+1].indent == indent && strings.HasPrefix(lines[i+1].text, "- "))) {
+			value, next, err := parseYAMLBlock(lines, i+1, lines[i+1].indent)
+			if err != nil {
+				return nil, 0, err
+			}
+			mapping[key] = value
+			i = next
+			continue
+		}
+		mapping[key] = nil
+		i++
+	}
+
+	if i < len(lines) && lines[i].indent > indent {
+		return nil, 0, fmt.Errorf("line %d: unexpected indentation", lines[i].no)
+	}
+	return mapping, i, nil
+}
+
+// parseYAMLScalar parses a scalar or flow sequence
+func parseYAMLScalar(s string) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(s, "["):
+		if !strings.HasSuffix(s, "]") {
+			return nil, fmt.Errorf("unterminated flow sequence")
+		}
+		items := []interface{}{}
+		inner := strings.TrimSpace(s[1 : len(s)-1])
+		for inner != "" {
+			end := indexOutsideQuotes(inner, ',')
+			if end < 0 {
+				end = len(inner)
+			}
+			item, err := parseYAMLScalar(strings.TrimSpace(inner[:end]))
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			if end == len(inner) {
+				break
+			}
+			inner = strings.TrimSpace(inner[end+1:])
+		}
+		return items, nil
+
+	case strings.HasPrefix(s, `"`):
+		value, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", s)
+		}
+		return value, nil
+
+	case strings.HasPrefix(s, "'"):
+		if len(s) < 2 || !strings.HasSuffix(s, "'") {
+			return nil, fmt.Errorf("unterminated string %s", s)
+		}
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+
+	// YAML 1.2 core schema: yes, no, on and off are strings
+	switch s {
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	case "null", "Null", "NULL", "~":
+		return nil, nil
+	}
+	if n, err := parseInteger(s); err == nil {
+		return n, nil
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f, nil
+	}
+	return s, nil
+}
+
+// stripYAMLComment removes a comment, which starts with '#' at the
+// beginning of the line or after whitespace
+func stripYAMLComment(line string) string {
+	for i := indexOutsideQuotes(line, '#'); i >= 0; {
+		if i == 0 || line[i-1] == ' ' || line[i-1] == '\t' {
+			return line[:i]
+		}
+		next := indexOutsideQuotes(line[i+1:], '#')
+		if next < 0 {
+			break
+		}
+		i += 1 + next
+	}
+	return line
+}
@@ -0,0 +1,67 @@
+package settings
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want map[string]interface{}
+	}{
+		{"empty", "---\n# nothing\n", m{}},
+		{"scalars", "a: x\nb: \"quoted: # here\"\nc: 'it''s'\nd: 42\ne: 1.5\nf: true\ng: FALSE\nh: ~\ni: null\n",
+			m{"a": "x", "b": "quoted: # here", "c": "it's", "d": int64(42), "e": 1.5, "f": true, "g": false, "h": nil, "i": nil}},
+		{"only true and false are booleans", "a: yes\nb: no\nc: on\nd: off\n", m{"a": "yes", "b": "no", "c": "on", "d": "off"}},
+		{"integers are decimal", "a: 010\nb: -7", m{"a": int64(10), "b": int64(-7)}},
+		{"explicit bases", "a: 0x1F\nb: 0o17\nc: 0b101", m{"a": int64(31), "b": int64(15), "c": int64(5)}},
+		{"comments", "a: x # comment\nb: x#not-a-comment\n# c: 1\n", m{"a": "x", "b": "x#not-a-comment"}},
+		{"colon in value", "url: http://example.com\ntime: 12:30", m{"url": "http://example.com", "time": "12:30"}},
+		{"nesting", "process:\n  max_attempts: 3\n  retry:\n    delay: 1s\nlog:\n  level: debug\n",
+			m{"process": m{"max_attempts": int64(3), "retry": m{"delay": "1s"}}, "log": m{"level": "debug"}}},
+		{"block sequence", "patterns:\n  - cursor\n  - \"Cursor Helper\"\n", m{"patterns": []interface{}{"cursor", "Cursor Helper"}}},
+		{"sequence at key indentation", "patterns:\n- a\n- b\nnext: 1\n", m{"patterns": []interface{}{"a", "b"}, "next": int64(1)}},
+		{"flow sequence", "a: [1, 'two', \"x, y\"]\nb: []\n", m{"a": []interface{}{int64(1), "two", "x, y"}, "b": []interface{}{}}},
+		{"sequence of mappings", "items:\n  -\n    name: a\n  -\n    name: b\n", m{"items": []interface{}{m{"name": "a"}, m{"name": "b"}}}},
+		{"empty value", "a:\nb: 1\n", m{"a": nil, "b": int64(1)}},
+		{"quoted key", "\"a: b\": 1\n", m{"a: b": int64(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.src))
+			if err != nil {
+				t.Fatalf("parseYAML: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %#v\nwant %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name, src, want string
+	}{
+		{"tab indentation", "a:\n\tb: 1", "line 2: tabs are not allowed"},
+		{"duplicate key", "a: 1\na: 2", `line 2: duplicate key "a"`},
+		{"duplicate nested key", "t:\n  a: 1\n  a: 2", `line 3: duplicate key "a"`},
+		{"no colon", "a: 1\njust text", "line 2: expected key: value"},
+		{"bad indentation", "a:\n    b: 1\n  c: 2", "line 3: unexpected indentation"},
+		{"unterminated flow", "a: [1, 2", "unterminated flow sequence"},
+		{"bad string", `a: "x\q"`, "invalid string"},
+		{"unterminated string", "a: 'x", "unterminated string"},
+		{"top-level sequence", "- a\n- b", "top-level value must be a mapping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML([]byte(tt.src))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"sync/atomic"
+
+	"github.com/fatih/color"
+	"github.com/mattn/go-isatty"
+)
+
+// colorDisabled is set by DisableColor
+var colorDisabled atomic.Bool
+
+// DisableColor turns off colour in all output, as requested by --no-color
+func DisableColor() {
+	colorDisabled.Store(true)
+	color.NoColor = true
+}
+
+// ColorEnabled reports whether colour is written to w: only to terminals,
+// and never when NO_COLOR is set or the terminal is dumb
+func ColorEnabled(w io.Writer) bool {
+	if colorDisabled.Load() || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(w)
+}
+
+// IsTerminal reports whether w is an interactive terminal
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && isTerminal(f)
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
+}
+
+// colorFor returns a colour writing to w, disabled when w should not get
+// colour
+func colorFor(w io.Writer, attrs ...color.Attribute) *color.Color {
+	c := color.New(attrs...)
+	if ColorEnabled(w) {
+		c.EnableColor()
+	} else {
+		c.DisableColor()
+	}
+	return c
+}
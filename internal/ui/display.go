@@ -2,31 +2,59 @@ package ui
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"runtime"
-	"strings"
 
 	"github.com/fatih/color"
+
+	"github.com/yuaotian/go-cursor-help/internal/redact"
 )
 
-// Display handles UI operations for terminal output
+// Display handles UI operations for terminal output. Colour and the
+// animated spinner are only used when the output is a terminal.
 type Display struct {
-	spinner *Spinner
+	out      io.Writer
+	spinner  *Spinner
+	redactor *redact.Redactor
 }
 
-// NewDisplay creates a new display instance with an optional spinner
-func NewDisplay(spinner *Spinner) *Display {
+// NewDisplay creates a new display instance writing to out or, if nil, to
+// stdout, with an optional spinner
+func NewDisplay(spinner *Spinner, out io.Writer) *Display {
+	if out == nil {
+		out = os.Stdout
+	}
 	if spinner == nil {
-		spinner = NewSpinner(nil)
+		spinner = NewSpinner(nil, out)
 	}
-	return &Display{spinner: spinner}
+	return &Display{out: out, spinner: spinner}
+}
+
+// Writer returns where the display writes
+func (d *Display) Writer() io.Writer {
+	return d.out
+}
+
+// IsTerminal reports whether the display writes to a terminal
+func (d *Display) IsTerminal() bool {
+	return IsTerminal(d.out)
+}
+
+// SetRedactor sets the redactor applied to displayed identifiers
+func (d *Display) SetRedactor(redactor *redact.Redactor) {
+	d.redactor = redactor
 }
 
 // Terminal Operations
 
-// ClearScreen clears the terminal screen based on OS
+// ClearScreen clears the terminal screen based on OS. Output that is not a
+// terminal is left alone.
 func (d *Display) ClearScreen() error {
+	if !IsTerminal(d.out) {
+		return nil
+	}
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "windows":
@@ -34,7 +62,7 @@ func (d *Display) ClearScreen() error {
 	default:
 		cmd = exec.Command("clear")
 	}
-	cmd.Stdout = os.Stdout
+	cmd.Stdout = d.out
 	return cmd.Run()
 }
 
@@ -55,40 +83,44 @@ func (d *Display) StopProgress() {
 
 // ShowSuccess displays success messages in green
 func (d *Display) ShowSuccess(messages ...string) {
-	green := color.New(color.FgGreen)
+	green := colorFor(d.out, color.FgGreen)
 	for _, msg := range messages {
-		green.Println(msg)
+		green.Fprintln(d.out, msg)
 	}
 }
 
 // ShowInfo displays an info message in cyan
 func (d *Display) ShowInfo(message string) {
-	cyan := color.New(color.FgCyan)
-	cyan.Println(message)
+	colorFor(d.out, color.FgCyan).Fprintln(d.out, message)
+}
+
+// ShowWarning displays a warning message in yellow
+func (d *Display) ShowWarning(message string) {
+	colorFor(d.out, color.FgYellow).Fprintln(d.out, message)
 }
 
 // ShowError displays an error message in red
 func (d *Display) ShowError(message string) {
-	red := color.New(color.FgRed)
-	red.Println(message)
+	colorFor(d.out, color.FgRed).Fprintln(d.out, message)
+}
+
+// ShowIdentifier displays a labelled identifier, masked by the redactor
+func (d *Display) ShowIdentifier(name, value string) {
+	colorFor(d.out, color.FgCyan).Fprintf(d.out, "  %-24s ", name+":")
+	fmt.Fprintln(d.out, d.redactor.Redact(value))
 }
 
 // ShowPrivilegeError displays privilege error messages with instructions
 func (d *Display) ShowPrivilegeError(messages ...string) {
-	red := color.New(color.FgRed, color.Bold)
-	yellow := color.New(color.FgYellow)
+	red := colorFor(d.out, color.FgRed, color.Bold)
+	yellow := colorFor(d.out, color.FgYellow)
 
 	// Main error message
-	red.Println(messages[0])
-	fmt.Println()
+	red.Fprintln(d.out, messages[0])
+	fmt.Fprintln(d.out)
 
 	// Additional instructions
 	for _, msg := range messages[1:] {
-		if strings.Contains(msg, "%s") {
-			exe, _ := os.Executable()
-			yellow.Printf(msg+"\n", exe)
-		} else {
-			yellow.Println(msg)
-		}
+		yellow.Fprintln(d.out, msg)
 	}
 }
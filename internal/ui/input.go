@@ -0,0 +1,25 @@
+package ui
+
+import (
+	"os"
+	"sync"
+)
+
+// HideInput stops the terminal on f from echoing what is typed, e.g. while
+// a passphrase is read, until the returned function is called. It does
+// nothing when f is not a terminal. The returned function may be called
+// more than once, so it can also be registered to run on interrupt.
+func HideInput(f *os.File) (restore func(), err error) {
+	if !isTerminal(f) {
+		return func() {}, nil
+	}
+	fd := int(f.Fd())
+	state, err := disableEcho(fd)
+	if err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() { restoreEcho(fd, state) })
+	}, nil
+}
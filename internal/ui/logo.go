@@ -15,6 +15,5 @@ const cyberpunkLogo = `
 
 // ShowLogo displays the application logo
 func (d *Display) ShowLogo() {
-	cyan := color.New(color.FgCyan, color.Bold)
-	cyan.Println(cyberpunkLogo)
+	colorFor(d.out, color.FgCyan, color.Bold).Fprint(d.out, cyberpunkLogo+"\n")
 }
@@ -0,0 +1,266 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/yuaotian/go-cursor-help/internal/redact"
+)
+
+// ErrNotTerminal is returned by OpenScreen when stdin or stdout is not an
+// interactive terminal
+var ErrNotTerminal = errors.New("not an interactive terminal")
+
+// Key identifies a key read from an interactive screen
+type Key int
+
+// Keys reported by ReadKey. Printable characters are KeyRune.
+const (
+	KeyRune Key = iota
+	KeyUp
+	KeyDown
+	KeyLeft
+	KeyRight
+	KeyHome
+	KeyEnd
+	KeyEnter
+	KeySpace
+	KeyTab
+	KeyBackspace
+	KeyEscape
+	KeyInterrupt // Ctrl-C, which does not raise a signal in raw mode
+	KeyUnknown
+)
+
+// Keypress is a key read from an interactive screen
+type Keypress struct {
+	Key  Key
+	Rune rune // The character for KeyRune
+}
+
+// Is reports whether k is the printable character r
+func (k Keypress) Is(r rune) bool {
+	return k.Key == KeyRune && k.Rune == r
+}
+
+// Terminal control sequences
+const (
+	enterAltScreen = "\033[?1049h"
+	exitAltScreen  = "\033[?1049l"
+	hideCursor     = "\033[?25l"
+	showCursor     = "\033[?25h"
+	cursorHome     = "\033[H"
+	clearLine      = "\033[K"
+	clearBelow     = "\033[J"
+)
+
+// Screen is a full-screen, keyboard-driven view on the terminal. It uses
+// the alternate screen, so the previous terminal contents come back on
+// Close.
+type Screen struct {
+	in       *os.File
+	out      *os.File
+	state    *terminalState
+	redactor *redact.Redactor
+	pending  []byte // Read but not yet decoded, e.g. pasted text
+	mu       sync.Mutex
+	closed   bool
+}
+
+// OpenScreen switches the terminal to raw mode and the alternate screen.
+// Callers must Close the screen, also when interrupted, to restore the
+// terminal.
+func (d *Display) OpenScreen() (*Screen, error) {
+	out, ok := d.out.(*os.File)
+	if !ok || !isTerminal(out) || !isTerminal(os.Stdin) {
+		return nil, ErrNotTerminal
+	}
+	in := os.Stdin
+	d.StopProgress()
+
+	state, err := makeRaw(int(in.Fd()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enter raw mode: %w", err)
+	}
+	s := &Screen{in: in, out: out, state: state, redactor: d.redactor}
+	fmt.Fprint(out, enterAltScreen+hideCursor)
+	return s, nil
+}
+
+// Close restores the terminal. It is safe to call more than once and from
+// another goroutine, e.g. an interrupt handler.
+func (s *Screen) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	fmt.Fprint(s.out, showCursor+exitAltScreen)
+	return restoreTerminal(int(s.in.Fd()), s.state)
+}
+
+// Size returns the width and height of the screen in cells, or 80x24 when
+// the terminal does not report it
+func (s *Screen) Size() (int, int) {
+	width, height, err := terminalSize(int(s.in.Fd()))
+	if err != nil || width <= 0 || height <= 0 {
+		return 80, 24
+	}
+	return width, height
+}
+
+// Redact masks an identifier like Display.ShowIdentifier does
+func (s *Screen) Redact(value string) string {
+	return s.redactor.Redact(value)
+}
+
+// Draw replaces the screen contents with lines. Lines beyond the height of
+// the screen are dropped; callers fit long lines with Truncate before
+// adding colour.
+func (s *Screen) Draw(lines []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+
+	_, height := s.Size()
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	var b strings.Builder
+	b.WriteString(cursorHome)
+	for i, line := range lines {
+		if i > 0 {
+			// Raw mode does not turn \n into \r\n
+			b.WriteString("\r\n")
+		}
+		b.WriteString(line)
+		b.WriteString(clearLine)
+	}
+	b.WriteString(clearBelow)
+	fmt.Fprint(s.out, b.String())
+}
+
+// ReadKey blocks until a key is pressed. Keys that arrive together, e.g.
+// when typing fast, are returned one per call.
+func (s *Screen) ReadKey() (Keypress, error) {
+	if len(s.pending) == 0 {
+		buf := make([]byte, 64)
+		n, err := s.in.Read(buf)
+		if err != nil {
+			return Keypress{}, err
+		}
+		s.pending = buf[:n]
+	}
+	key, n := parseKey(s.pending)
+	s.pending = s.pending[n:]
+	return key, nil
+}
+
+// parseKey decodes the first key press in b and returns the number of
+// bytes it used. Escape sequences arrive in a single read, which
+// separates them from a lone Escape.
+func parseKey(b []byte) (Keypress, int) {
+	if len(b) == 0 {
+		return Keypress{Key: KeyUnknown}, 0
+	}
+	switch b[0] {
+	case 3:
+		return Keypress{Key: KeyInterrupt}, 1
+	case '\r', '\n':
+		return Keypress{Key: KeyEnter}, 1
+	case '\t':
+		return Keypress{Key: KeyTab}, 1
+	case ' ':
+		return Keypress{Key: KeySpace}, 1
+	case 8, 127:
+		return Keypress{Key: KeyBackspace}, 1
+	case 27:
+		if len(b) < 3 || (b[1] != '[' && b[1] != 'O') {
+			return Keypress{Key: KeyEscape}, 1
+		}
+		// Skip parameters such as the "1;5" of Ctrl+arrow
+		end := 2
+		for end < len(b)-1 && (b[end] >= '0' && b[end] <= '9' || b[end] == ';') {
+			end++
+		}
+		keys := map[byte]Key{'A': KeyUp, 'B': KeyDown, 'C': KeyRight, 'D': KeyLeft, 'H': KeyHome, 'F': KeyEnd}
+		if key, ok := keys[b[end]]; ok {
+			return Keypress{Key: key}, end + 1
+		}
+		return Keypress{Key: KeyUnknown}, end + 1
+	}
+	r, size := utf8.DecodeRune(b)
+	if r == utf8.RuneError || r < ' ' {
+		return Keypress{Key: KeyUnknown}, size
+	}
+	return Keypress{Key: KeyRune, Rune: r}, size
+}
+
+// Truncate shortens s to at most width terminal columns, marking the cut
+// with an ellipsis
+func Truncate(s string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	if stringWidth(s) <= width {
+		return s
+	}
+	used := 0
+	for i, r := range s {
+		// Leave a column for the ellipsis
+		if used+runeWidth(r) > width-1 {
+			return s[:i] + "…"
+		}
+		used += runeWidth(r)
+	}
+	return s
+}
+
+// stringWidth returns the number of terminal columns s takes
+func stringWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
+
+// wideRanges are the East Asian wide and fullwidth characters, which take
+// two columns: CJK ideographs, kana, Hangul, fullwidth forms and emoji
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF},
+	{0x20000, 0x3FFFD},
+}
+
+// runeWidth returns the number of terminal columns r takes
+func runeWidth(r rune) int {
+	if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0 // Combining marks and zero-width characters
+	}
+	for _, wide := range wideRanges {
+		if r >= wide[0] && r <= wide[1] {
+			return 2
+		}
+	}
+	return 1
+}
@@ -0,0 +1,51 @@
+package ui
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{"storage.json", 20, "storage.json"},
+		{"storage.json", 12, "storage.json"},
+		{"storage.json", 8, "storage…"},
+		{"storage.json", 1, "…"},
+		{"storage.json", 0, ""},
+		{"Grüße", 4, "Grü…"},
+		// Wide characters take two columns each
+		{"配置文件", 8, "配置文件"},
+		{"配置文件", 7, "配置文…"},
+		{"配置文件", 6, "配置…"},
+		{" 备份 (3 个)", 9, " 备份 (3…"},
+		{"バックアップ", 5, "バッ…"},
+		{"ｆｕｌｌ", 4, "ｆ…"},
+		{"e\u0301e\u0301", 2, "e\u0301e\u0301"}, // Combining accents take none
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.s, tt.width)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q, want %q", tt.s, tt.width, got, tt.want)
+		}
+		if w := stringWidth(got); w > tt.width {
+			t.Errorf("Truncate(%q, %d) is %d columns wide", tt.s, tt.width, w)
+		}
+	}
+}
+
+func TestStringWidth(t *testing.T) {
+	tests := map[string]int{
+		"":                  0,
+		"Cursor":            6,
+		"Profile":           7,
+		"プロファイル":            12,
+		"已关闭 1 个 Cursor 进程": 23,
+		"↑/↓":               3,
+	}
+	for s, want := range tests {
+		if got := stringWidth(s); got != want {
+			t.Errorf("stringWidth(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
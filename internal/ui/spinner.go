@@ -2,6 +2,8 @@ package ui
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"sync"
 	"time"
 
@@ -10,8 +12,8 @@ import (
 
 // SpinnerConfig defines spinner configuration
 type SpinnerConfig struct {
-	Frames []string        // Animation frames for the spinner
-	Delay  time.Duration   // Delay between frame updates
+	Frames []string      // Animation frames for the spinner
+	Delay  time.Duration // Delay between frame updates
 }
 
 // DefaultSpinnerConfig returns the default spinner configuration
@@ -22,24 +24,34 @@ func DefaultSpinnerConfig() *SpinnerConfig {
 	}
 }
 
-// Spinner represents a progress spinner
+// Spinner represents a progress spinner. On a terminal it animates in
+// place; otherwise each message is written once as a plain line, so that
+// logs of piped output stay readable.
 type Spinner struct {
-	config  *SpinnerConfig
-	message string
-	current int
-	active  bool
-	stopCh  chan struct{}
-	mu      sync.RWMutex
+	config      *SpinnerConfig
+	out         io.Writer
+	interactive bool // Whether out is a terminal that can be redrawn
+	message     string
+	current     int
+	active      bool
+	stopCh      chan struct{}
+	doneCh      chan struct{}
+	mu          sync.Mutex
 }
 
-// NewSpinner creates a new spinner with the given configuration
-func NewSpinner(config *SpinnerConfig) *Spinner {
+// NewSpinner creates a new spinner with the given configuration, writing
+// to out or, if nil, to stdout
+func NewSpinner(config *SpinnerConfig, out io.Writer) *Spinner {
 	if config == nil {
 		config = DefaultSpinnerConfig()
 	}
+	if out == nil {
+		out = os.Stdout
+	}
 	return &Spinner{
-		config: config,
-		stopCh: make(chan struct{}),
+		config:      config,
+		out:         out,
+		interactive: IsTerminal(out),
 	}
 }
 
@@ -49,13 +61,16 @@ func NewSpinner(config *SpinnerConfig) *Spinner {
 func (s *Spinner) SetMessage(message string) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	if s.active && !s.interactive && message != s.message {
+		fmt.Fprintln(s.out, message)
+	}
 	s.message = message
 }
 
 // IsActive returns whether the spinner is currently active
 func (s *Spinner) IsActive() bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	return s.active
 }
 
@@ -64,59 +79,74 @@ func (s *Spinner) IsActive() bool {
 // Start begins the spinner animation
 func (s *Spinner) Start() {
 	s.mu.Lock()
+	defer s.mu.Unlock()
 	if s.active {
-		s.mu.Unlock()
 		return
 	}
 	s.active = true
-	s.mu.Unlock()
 
-	go s.run()
+	if !s.interactive {
+		fmt.Fprintln(s.out, s.message)
+		return
+	}
+	s.stopCh = make(chan struct{})
+	s.doneCh = make(chan struct{})
+	s.draw(true)
+	go s.run(s.stopCh, s.doneCh)
 }
 
-// Stop halts the spinner animation
+// Stop halts the spinner animation and waits until it has stopped drawing
 func (s *Spinner) Stop() {
 	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	if !s.active {
+		s.mu.Unlock()
 		return
 	}
-
 	s.active = false
+	if !s.interactive {
+		s.mu.Unlock()
+		return
+	}
 	close(s.stopCh)
-	s.stopCh = make(chan struct{})
-	fmt.Print("\r") // Clear the spinner line
+	done := s.doneCh
+	s.mu.Unlock()
+
+	<-done
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fmt.Fprint(s.out, "\r") // Return to the start of the spinner line
 }
 
 // Internal methods
 
-func (s *Spinner) run() {
+func (s *Spinner) run(stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
 	ticker := time.NewTicker(s.config.Delay)
 	defer ticker.Stop()
 
-	cyan := color.New(color.FgCyan, color.Bold)
-	message := s.message
-
-	// Print initial state
-	fmt.Printf("\r %s %s", cyan.Sprint(s.config.Frames[0]), message)
-
+	shown := ""
 	for {
 		select {
-		case <-s.stopCh:
+		case <-stop:
 			return
 		case <-ticker.C:
-			s.mu.RLock()
-			if !s.active {
-				s.mu.RUnlock()
-				return
-			}
-			frame := s.config.Frames[s.current%len(s.config.Frames)]
+			s.mu.Lock()
 			s.current++
-			s.mu.RUnlock()
-
-			fmt.Printf("\r %s", cyan.Sprint(frame))
-			fmt.Printf("\033[%dG%s", 4, message) // Move cursor and print message
+			changed := s.message != shown
+			shown = s.message
+			s.draw(changed)
+			s.mu.Unlock()
 		}
 	}
 }
+
+// draw writes the current frame and message; clear erases the rest of a
+// longer previous message. The caller holds s.mu.
+func (s *Spinner) draw(clear bool) {
+	cyan := colorFor(s.out, color.FgCyan, color.Bold)
+	frame := s.config.Frames[s.current%len(s.config.Frames)]
+	fmt.Fprintf(s.out, "\r %s %s", cyan.Sprint(frame), s.message)
+	if clear {
+		fmt.Fprint(s.out, "\033[K")
+	}
+}
@@ -0,0 +1,67 @@
+package ui
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSpinnerWithoutTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewSpinner(nil, &buf)
+
+	s.SetMessage("Detecting")
+	s.Start()
+	s.Start()
+	s.SetMessage("Detecting")
+	s.SetMessage("Stopping")
+	s.Stop()
+	s.Stop()
+	s.SetMessage("Backing up") // Not shown until started again
+	s.Start()
+	s.Stop()
+
+	if want := "Detecting\nStopping\nBacking up\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestSpinnerOnTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	// A buffer stands in for the terminal; the delay keeps the frame still
+	s := &Spinner{
+		config:      &SpinnerConfig{Frames: []string{"-"}, Delay: time.Hour},
+		out:         &buf,
+		interactive: true,
+	}
+
+	s.SetMessage("Detecting")
+	s.Start()
+	if !s.IsActive() {
+		t.Error("IsActive = false after Start")
+	}
+	s.Stop()
+	if s.IsActive() {
+		t.Error("IsActive = true after Stop")
+	}
+
+	// Drawn in place and left at the start of the line, without a newline
+	if want := "\r - Detecting\033[K\r"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestDisplayIsTerminal(t *testing.T) {
+	var buf bytes.Buffer
+	display := NewDisplay(nil, &buf)
+	if display.IsTerminal() {
+		t.Error("IsTerminal = true for a buffer")
+	}
+
+	display.ShowProgress("Writing")
+	display.ShowProgress("Verifying")
+	display.StopProgress()
+	if want := "Writing\nVerifying\n"; buf.String() != want {
+		t.Errorf("output = %q, want %q", buf.String(), want)
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !linux && !darwin && !windows
+
+package ui
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// terminalState is the saved mode of a terminal
+type terminalState struct{}
+
+func makeRaw(fd int) (*terminalState, error) {
+	return nil, fmt.Errorf("interactive mode is not supported on %s", runtime.GOOS)
+}
+
+func restoreTerminal(fd int, state *terminalState) error {
+	return nil
+}
+
+func disableEcho(fd int) (*terminalState, error) {
+	return nil, fmt.Errorf("hiding input is not supported on %s", runtime.GOOS)
+}
+
+func restoreEcho(fd int, state *terminalState) error {
+	return nil
+}
+
+func terminalSize(fd int) (int, int, error) {
+	return 0, 0, fmt.Errorf("unsupported operating system: %s", runtime.GOOS)
+}
@@ -0,0 +1,67 @@
+//go:build linux || darwin
+
+package ui
+
+import "golang.org/x/sys/unix"
+
+// terminalState is the saved mode of a terminal
+type terminalState struct {
+	termios unix.Termios
+}
+
+// makeRaw puts the terminal in raw mode: no echo, no line buffering and
+// no signals from Ctrl-C, which is read as a key instead
+func makeRaw(fd int) (*terminalState, error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	state := &terminalState{termios: *termios}
+
+	termios.Iflag &^= unix.IGNBRK | unix.BRKINT | unix.PARMRK | unix.ISTRIP | unix.INLCR | unix.IGNCR | unix.ICRNL | unix.IXON
+	termios.Oflag &^= unix.OPOST
+	termios.Lflag &^= unix.ECHO | unix.ECHONL | unix.ICANON | unix.ISIG | unix.IEXTEN
+	termios.Cflag &^= unix.CSIZE | unix.PARENB
+	termios.Cflag |= unix.CS8
+	termios.Cc[unix.VMIN] = 1
+	termios.Cc[unix.VTIME] = 0
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// restoreTerminal returns the terminal to a mode saved by makeRaw
+func restoreTerminal(fd int, state *terminalState) error {
+	return unix.IoctlSetTermios(fd, ioctlSetTermios, &state.termios)
+}
+
+// terminalSize returns the width and height of the terminal in cells
+func terminalSize(fd int) (int, int, error) {
+	ws, err := unix.IoctlGetWinsize(fd, unix.TIOCGWINSZ)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(ws.Col), int(ws.Row), nil
+}
+
+// disableEcho stops the terminal from echoing input, leaving line editing
+// and signals as they are
+func disableEcho(fd int) (*terminalState, error) {
+	termios, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		return nil, err
+	}
+	state := &terminalState{termios: *termios}
+
+	termios.Lflag &^= unix.ECHO
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, termios); err != nil {
+		return nil, err
+	}
+	return state, nil
+}
+
+// restoreEcho returns the terminal to a mode saved by disableEcho
+func restoreEcho(fd int, state *terminalState) error {
+	return restoreTerminal(fd, state)
+}
@@ -0,0 +1,69 @@
+package ui
+
+import "golang.org/x/sys/windows"
+
+// terminalState is the saved mode of a console
+type terminalState struct {
+	inMode, outMode uint32
+}
+
+// makeRaw puts the console in raw mode with virtual terminal sequences:
+// no echo, no line buffering and Ctrl-C read as a key. The output handle
+// is the standard output, which must also be a console.
+func makeRaw(fd int) (*terminalState, error) {
+	in, out := windows.Handle(fd), windows.Handle(windows.Stdout)
+	var state terminalState
+	if err := windows.GetConsoleMode(in, &state.inMode); err != nil {
+		return nil, err
+	}
+	if err := windows.GetConsoleMode(out, &state.outMode); err != nil {
+		return nil, err
+	}
+
+	inMode := state.inMode&^(windows.ENABLE_ECHO_INPUT|windows.ENABLE_LINE_INPUT|windows.ENABLE_PROCESSED_INPUT) |
+		windows.ENABLE_VIRTUAL_TERMINAL_INPUT
+	if err := windows.SetConsoleMode(in, inMode); err != nil {
+		return nil, err
+	}
+	outMode := state.outMode | windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING | windows.DISABLE_NEWLINE_AUTO_RETURN
+	if err := windows.SetConsoleMode(out, outMode); err != nil {
+		windows.SetConsoleMode(in, state.inMode)
+		return nil, err
+	}
+	return &state, nil
+}
+
+// restoreTerminal returns the console to a mode saved by makeRaw
+func restoreTerminal(fd int, state *terminalState) error {
+	if err := windows.SetConsoleMode(windows.Handle(windows.Stdout), state.outMode); err != nil {
+		return err
+	}
+	return windows.SetConsoleMode(windows.Handle(fd), state.inMode)
+}
+
+// terminalSize returns the width and height of the console window in cells
+func terminalSize(fd int) (int, int, error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err := windows.GetConsoleScreenBufferInfo(windows.Handle(windows.Stdout), &info); err != nil {
+		return 0, 0, err
+	}
+	return int(info.Window.Right-info.Window.Left) + 1, int(info.Window.Bottom-info.Window.Top) + 1, nil
+}
+
+// disableEcho stops the console from echoing input, leaving line editing
+// and Ctrl-C as they are. Only the input mode is saved.
+func disableEcho(fd int) (*terminalState, error) {
+	var state terminalState
+	if err := windows.GetConsoleMode(windows.Handle(fd), &state.inMode); err != nil {
+		return nil, err
+	}
+	if err := windows.SetConsoleMode(windows.Handle(fd), state.inMode&^windows.ENABLE_ECHO_INPUT); err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// restoreEcho returns the console to a mode saved by disableEcho
+func restoreEcho(fd int, state *terminalState) error {
+	return windows.SetConsoleMode(windows.Handle(fd), state.inMode)
+}
@@ -77,7 +77,13 @@ func (g *Generator) GenerateSQMID() (string, error) {
 // ValidateID validates the format of various ID types
 func (g *Generator) ValidateID(id string, idType string) bool {
 	switch idType {
-	case "machineID", "macMachineID":
+	case "machineID":
+		// Generated machine IDs carry the hex-encoded auth0|user_ prefix
+		if prefix := fmt.Sprintf("%x", []byte(machineIDPrefix)); len(id) == len(prefix)+64 && id[:len(prefix)] == prefix {
+			id = id[len(prefix):]
+		}
+		return len(id) == 64 && isHexString(id)
+	case "macMachineID":
 		return len(id) == 64 && isHexString(id)
 	case "deviceID":
 		return isValidUUID(id)
@@ -0,0 +1,91 @@
+package idgen
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedIDsValidate(t *testing.T) {
+	g := NewGenerator()
+	generators := map[string]func() (string, error){
+		"machineID":    g.GenerateMachineID,
+		"macMachineID": g.GenerateMacMachineID,
+		"deviceID":     g.GenerateDeviceID,
+		"sqmID":        g.GenerateSQMID,
+	}
+	for idType, generate := range generators {
+		seen := make(map[string]bool)
+		for i := 0; i < 20; i++ {
+			id, err := generate()
+			if err != nil {
+				t.Fatalf("%s: %v", idType, err)
+			}
+			if !g.ValidateID(id, idType) {
+				t.Errorf("%s: generated %q does not validate", idType, id)
+			}
+			if seen[id] {
+				t.Errorf("%s: %q generated twice", idType, id)
+			}
+			seen[id] = true
+		}
+	}
+}
+
+func TestGenerateMachineIDPrefix(t *testing.T) {
+	id, err := NewGenerator().GenerateMachineID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := hex.EncodeToString([]byte(machineIDPrefix))
+	if !strings.HasPrefix(id, prefix) || len(id) != len(prefix)+64 {
+		t.Errorf("GenerateMachineID = %q, want %s and 64 hex digits", id, prefix)
+	}
+}
+
+func TestValidateID(t *testing.T) {
+	prefix := hex.EncodeToString([]byte(machineIDPrefix))
+	hex64 := strings.Repeat("0123456789abcdef", 4)
+	uuid := "01234567-89ab-cdef-0123-456789ABCDEF"
+
+	tests := []struct {
+		id, idType string
+		want       bool
+	}{
+		{hex64, "machineID", true},
+		{prefix + hex64, "machineID", true},
+		{prefix, "machineID", false},
+		{prefix + hex64[:62], "machineID", false},
+		{prefix + hex64 + "00", "machineID", false},
+		{hex64[:62], "machineID", false},
+		{hex64 + "00", "machineID", false},
+		{strings.Repeat("g", 64), "machineID", false},
+		{prefix + strings.Repeat("g", 64), "machineID", false},
+		{"auth0|user_" + hex64, "machineID", false},
+		{"", "machineID", false},
+
+		{hex64, "macMachineID", true},
+		{prefix + hex64, "macMachineID", false},
+		{hex64[:63] + "x", "macMachineID", false},
+
+		{uuid, "deviceID", true},
+		{"{" + uuid + "}", "deviceID", false},
+		{strings.ReplaceAll(uuid, "-", ""), "deviceID", false},
+		{uuid[:35] + "g", "deviceID", false},
+		{"01234567-89ab-cdef-0123_456789abcdef", "deviceID", false},
+
+		{"{" + uuid + "}", "sqmID", true},
+		{uuid, "sqmID", false},
+		{"{" + uuid, "sqmID", false},
+		{"{}", "sqmID", false},
+		{"{", "sqmID", false},
+
+		{hex64, "unknown", false},
+	}
+	g := NewGenerator()
+	for _, tt := range tests {
+		if got := g.ValidateID(tt.id, tt.idType); got != tt.want {
+			t.Errorf("ValidateID(%q, %s) = %v, want %v", tt.id, tt.idType, got, tt.want)
+		}
+	}
+}